@@ -0,0 +1,60 @@
+package adb
+
+import (
+	"regexp"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+// "* Hist #2: ActivityRecord{8f1c2a u0 com.example/.MainActivity t123}"
+var activityRecordRe = regexp.MustCompile(`Hist #\d+: ActivityRecord\{(\w+) u(\d+) (\S+/\S+) t(\d+)`)
+
+// ActivityRecord is an activity of the activity stack.
+type ActivityRecord struct {
+	Hash      string
+	User      string
+	Component string
+	TaskID    string
+}
+
+// PackageName returns the package part of the component.
+func (r *ActivityRecord) PackageName() string {
+	pkg, _, _ := strings.Cut(r.Component, "/")
+	return pkg
+}
+
+// GetActivityStack returns the activity records from top to bottom.
+func GetActivityStack() ([]*ActivityRecord, error) {
+	output, err := util.Exec("adb shell dumpsys activity activities", false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ParseActivityStack(output), nil
+}
+
+// ParseActivityStack parses the Hist lines of `dumpsys activity activities`.
+// Components are expanded, com.example/.Main becomes com.example/com.example.Main.
+func ParseActivityStack(output string) []*ActivityRecord {
+	var records []*ActivityRecord
+	seen := make(map[string]bool)
+	for _, line := range util.MultiLine(output) {
+		m := activityRecordRe.FindStringSubmatch(line)
+		if m == nil || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		records = append(records, &ActivityRecord{Hash: m[1], User: m[2], Component: expandComponent(m[3]), TaskID: m[4]})
+	}
+	return records
+}
+
+// TopActivityOf returns the topmost activity record of a package, nil when it has none.
+func TopActivityOf(records []*ActivityRecord, packageName string) *ActivityRecord {
+	for _, r := range records {
+		if r.PackageName() == packageName {
+			return r
+		}
+	}
+	return nil
+}
@@ -1,15 +1,33 @@
 package adb
 
 import (
+	"fmt"
 	"rabbit-go/util"
 	"strings"
 )
 
 func GetCurrentPackageAndActivityName() (string, error) {
-	result, err := util.Exec(`adb shell dumpsys activity activities | grep mResumedActivity | awk '{print $4}'`, false, nil)
+	return currentPackageAndActivityName(false)
+}
+
+// TryGetCurrentPackageAndActivityName is GetCurrentPackageAndActivityName for polling loops,
+// adb errors such as a disconnected device are returned instead of exiting
+func TryGetCurrentPackageAndActivityName() (string, error) {
+	result, err := currentPackageAndActivityName(true)
+	if err != nil {
+		return "", err
+	}
+	if fields := strings.Fields(result); len(fields) != 1 || !strings.Contains(fields[0], "/") {
+		return "", fmt.Errorf("cannot get current activity: %s", strings.TrimSpace(result))
+	}
+	return result, nil
+}
+
+func currentPackageAndActivityName(ignoreError bool) (string, error) {
+	result, err := util.Exec(`adb shell dumpsys activity activities | grep mResumedActivity | awk '{print $4}'`, ignoreError, nil)
 
 	if err != nil || strings.TrimSpace(result) == "" {
-		result, err = util.Exec(`adb shell dumpsys activity activities | grep ResumedActivity | grep -v top | awk '{print $4}'`, false, nil)
+		result, err = util.Exec(`adb shell dumpsys activity activities | grep ResumedActivity | grep -v top | awk '{print $4}'`, ignoreError, nil)
 		if err != nil {
 			return "", err
 		}
@@ -27,3 +45,8 @@ func GetActivityListStringFromTopToBottom() (string, error) {
 func Exec(command string, ignoreError bool, exitWhen func(string) bool) (string, error) {
 	return util.Exec(command, ignoreError, exitWhen)
 }
+
+// QuoteRemote quotes s so it survives both the local shell and `adb shell` as a single argument
+func QuoteRemote(s string) string {
+	return util.ShellQuote(util.ShellQuote(s))
+}
@@ -0,0 +1,149 @@
+package adb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	apkSigBlockMagic = "APK Sig Block 42"
+	apkSigV2BlockID  = 0x7109871a
+	apkSigV3BlockID  = 0xf05368c0
+	eocdSignature    = 0x06054b50
+)
+
+// CertificateDigests returns the SHA-256 digests of the signing certificates
+// stored in the APK Signature Scheme v3 or v2 block of a local apk.
+func CertificateDigests(apkPath string) ([]string, error) {
+	data, err := os.ReadFile(apkPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cdOffset, err := centralDirectoryOffset(data)
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := signingBlockPairs(data, cdOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range []uint32{apkSigV3BlockID, apkSigV2BlockID} {
+		value, ok := pairs[id]
+		if !ok {
+			continue
+		}
+		certs, err := signerCertificates(value)
+		if err != nil {
+			return nil, err
+		}
+		var digests []string
+		for _, cert := range certs {
+			sum := sha256.Sum256(cert)
+			digests = append(digests, hex.EncodeToString(sum[:]))
+		}
+		return digests, nil
+	}
+	return nil, errors.New("apk has no v2/v3 signature block")
+}
+
+func centralDirectoryOffset(data []byte) (int, error) {
+	// the end of central directory record is 22 bytes plus an optional comment of up to 64KB
+	for i := len(data) - 22; i >= 0 && i >= len(data)-22-0xffff; i-- {
+		if binary.LittleEndian.Uint32(data[i:]) == eocdSignature {
+			return int(binary.LittleEndian.Uint32(data[i+16:])), nil
+		}
+	}
+	return 0, errors.New("not a zip file")
+}
+
+func signingBlockPairs(data []byte, cdOffset int) (map[uint32][]byte, error) {
+	if cdOffset < 24 || cdOffset > len(data) {
+		return nil, errors.New("invalid central directory offset")
+	}
+	footer := data[cdOffset-24 : cdOffset]
+	if string(footer[8:]) != apkSigBlockMagic {
+		return nil, errors.New("apk has no signing block")
+	}
+	// the size covers the pairs and the 24 byte footer, and is preceded by its own 8 byte copy
+	size := binary.LittleEndian.Uint64(footer)
+	if size < 24 || size > uint64(cdOffset-8) {
+		return nil, errors.New("invalid signing block size")
+	}
+	start := cdOffset - int(size) - 8
+
+	pairs := make(map[uint32][]byte)
+	r := bytes.NewReader(data[start+8 : cdOffset-24])
+	for r.Len() > 0 {
+		var length uint64
+		var id uint32
+		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &id); err != nil {
+			return nil, err
+		}
+		if length < 4 || length-4 > uint64(r.Len()) {
+			return nil, errors.New("invalid signing block entry")
+		}
+		value := make([]byte, length-4)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, err
+		}
+		pairs[id] = value
+	}
+	return pairs, nil
+}
+
+// signerCertificates walks signers -> signed data -> certificates of a v2/v3 block.
+func signerCertificates(block []byte) ([][]byte, error) {
+	signers, _, err := lengthPrefixed(block)
+	if err != nil {
+		return nil, err
+	}
+	var certs [][]byte
+	for len(signers) > 0 {
+		var signer []byte
+		if signer, signers, err = lengthPrefixed(signers); err != nil {
+			return nil, err
+		}
+		signedData, _, err := lengthPrefixed(signer)
+		if err != nil {
+			return nil, err
+		}
+		_, rest, err := lengthPrefixed(signedData) // digests
+		if err != nil {
+			return nil, err
+		}
+		certList, _, err := lengthPrefixed(rest)
+		if err != nil {
+			return nil, err
+		}
+		for len(certList) > 0 {
+			var cert []byte
+			if cert, certList, err = lengthPrefixed(certList); err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		}
+	}
+	return certs, nil
+}
+
+func lengthPrefixed(b []byte) ([]byte, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, fmt.Errorf("truncated signing block")
+	}
+	n := int(binary.LittleEndian.Uint32(b))
+	if n > len(b)-4 {
+		return nil, nil, fmt.Errorf("truncated signing block")
+	}
+	return b[4 : 4+n], b[4+n:], nil
+}
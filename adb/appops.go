@@ -0,0 +1,147 @@
+package adb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+var (
+	// Android 10-: "CAMERA: allow; time=+1h2m ago; rejectTime=+3m ago; duration=+12ms"
+	appOpLineRe = regexp.MustCompile(`^([A-Z0-9_]+): ([a-z]+)(;.*)?$`)
+	// Android 11+: "CAMERA (allow):" followed by indented Access/Reject lines
+	appOpHeaderRe = regexp.MustCompile(`^([A-Z0-9_]+) \(([a-z]+)\):$`)
+	// "Access: [top-s] 2024-01-01 10:00:00.123 (-3m12s345ms) duration=+12ms"
+	appOpAccessRe = regexp.MustCompile(`^(Access|Reject): .*\((-[^)]+)\)`)
+)
+
+// AppOpModes are the modes accepted by `cmd appops set`.
+var AppOpModes = []string{"allow", "ignore", "deny", "default", "foreground"}
+
+// AppOp is the mode and last access of an app op.
+type AppOp struct {
+	Name       string `json:"name"`
+	Mode       string `json:"mode"`
+	UIDMode    string `json:"uidMode,omitempty"`
+	LastAccess string `json:"lastAccess,omitempty"`
+	LastReject string `json:"lastReject,omitempty"`
+}
+
+// GetAppOps returns the app ops of a package, or only op when it is not empty.
+func GetAppOps(packageName, op string) ([]*AppOp, error) {
+	cmd := fmt.Sprintf("adb shell cmd appops get %s", packageName)
+	if op != "" {
+		cmd += " " + QuoteRemote(op)
+	}
+	output, err := util.Exec(cmd+" 2>&1", true, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := appOpsError(output); err != nil {
+		return nil, err
+	}
+	return ParseAppOps(output), nil
+}
+
+// ParseAppOps parses `cmd appops get` output of both the old single line and the newer block format.
+func ParseAppOps(output string) []*AppOp {
+	var ops []*AppOp
+	byName := make(map[string]*AppOp)
+	get := func(name string) *AppOp {
+		if op, ok := byName[name]; ok {
+			return op
+		}
+		op := &AppOp{Name: name}
+		byName[name] = op
+		ops = append(ops, op)
+		return op
+	}
+
+	var current *AppOp
+	for _, line := range nonEmptyLines(output) {
+		trimmed := strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(trimmed, "Uid mode: "); ok {
+			if m := appOpLineRe.FindStringSubmatch(rest); m != nil {
+				get(m[1]).UIDMode = m[2]
+			}
+			current = nil
+			continue
+		}
+		if m := appOpHeaderRe.FindStringSubmatch(trimmed); m != nil {
+			current = get(m[1])
+			current.Mode = m[2]
+			continue
+		}
+		if m := appOpLineRe.FindStringSubmatch(trimmed); m != nil {
+			current = nil
+			op := get(m[1])
+			op.Mode = m[2]
+			for _, part := range strings.Split(m[3], ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+				if !ok {
+					continue
+				}
+				switch key {
+				case "time":
+					op.LastAccess = strings.TrimPrefix(value, "+")
+				case "rejectTime":
+					op.LastReject = strings.TrimPrefix(value, "+")
+				}
+			}
+			continue
+		}
+		// the newest access of every attribution tag is listed first
+		if m := appOpAccessRe.FindStringSubmatch(trimmed); m != nil && current != nil {
+			ago := strings.TrimPrefix(m[2], "-") + " ago"
+			if m[1] == "Access" && current.LastAccess == "" {
+				current.LastAccess = ago
+			} else if m[1] == "Reject" && current.LastReject == "" {
+				current.LastReject = ago
+			}
+		}
+	}
+	return ops
+}
+
+// SetAppOp sets the mode of an op, for the whole uid when uid is true.
+func SetAppOp(packageName, op, mode string, uid bool) error {
+	cmd := "adb shell cmd appops set"
+	if uid {
+		cmd += " --uid"
+	}
+	output, err := util.Exec(fmt.Sprintf("%s %s %s %s 2>&1", cmd, packageName, QuoteRemote(op), QuoteRemote(mode)), true, nil)
+	if err != nil {
+		return err
+	}
+	return appOpsError(output)
+}
+
+// ResetAppOps resets every op of a package to its default mode.
+func ResetAppOps(packageName string) error {
+	output, err := util.Exec(fmt.Sprintf("adb shell cmd appops reset %s 2>&1", packageName), true, nil)
+	if err != nil {
+		return err
+	}
+	return appOpsError(output)
+}
+
+// AppOpName normalizes an op name, "system_alert_window" becomes SYSTEM_ALERT_WINDOW.
+// Public names such as android:system_alert_window are kept as they are.
+func AppOpName(name string) string {
+	if strings.Contains(name, ":") {
+		return name
+	}
+	return strings.ToUpper(name)
+}
+
+func appOpsError(output string) error {
+	for _, line := range util.MultiLine(output) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Error") || strings.Contains(line, "Exception") {
+			return fmt.Errorf("%s", strings.TrimPrefix(line, "Error: "))
+		}
+	}
+	return nil
+}
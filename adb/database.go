@@ -0,0 +1,108 @@
+package adb
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+const databasesDir = "databases"
+
+// databaseSuffixes are the companion files that belong to a database.
+var databaseSuffixes = []string{"-wal", "-shm", "-journal"}
+
+// Database is a file of the databases directory with its companion files.
+type Database struct {
+	Name    string           `json:"name"`
+	Size    int64            `json:"size"`
+	Related map[string]int64 `json:"related,omitempty"`
+}
+
+// ListDatabases lists the databases directory of a debuggable app.
+func ListDatabases(packageName string) ([]*Database, error) {
+	script := fmt.Sprintf("cd %s && stat -c '%%s %%n' *", databasesDir)
+	output, err := util.Exec(fmt.Sprintf("adb shell run-as %s sh -c %s", packageName, QuoteRemote(script)), true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[string]int64)
+	for _, line := range util.MultiLine(output) {
+		size, name, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if n, err := strconv.ParseInt(size, 10, 64); ok && err == nil {
+			sizes[name] = n
+		}
+	}
+
+	var databases []*Database
+	for name, size := range sizes {
+		if databaseBase(name) != name {
+			continue
+		}
+		db := &Database{Name: name, Size: size}
+		for _, suffix := range databaseSuffixes {
+			if n, ok := sizes[name+suffix]; ok {
+				if db.Related == nil {
+					db.Related = make(map[string]int64)
+				}
+				db.Related[suffix] = n
+			}
+		}
+		databases = append(databases, db)
+	}
+	sort.Slice(databases, func(i, j int) bool {
+		return databases[i].Name < databases[j].Name
+	})
+	return databases, nil
+}
+
+// PullDatabase copies a database and its -wal/-shm files into dir and returns the local database path.
+func PullDatabase(packageName, name, dir string) (string, error) {
+	databases, err := ListDatabases(packageName)
+	if err != nil {
+		return "", err
+	}
+	var db *Database
+	for _, d := range databases {
+		if d.Name == name {
+			db = d
+		}
+	}
+	if db == nil {
+		return "", fmt.Errorf("database %s not found in %s", name, packageName)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	files := []string{db.Name}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if _, ok := db.Related[suffix]; ok {
+			files = append(files, db.Name+suffix)
+		}
+	}
+	for _, file := range files {
+		local := filepath.Join(dir, file)
+		remote := path.Join(databasesDir, file)
+		cmd := fmt.Sprintf("adb exec-out run-as %s cat %s > %s", packageName, QuoteRemote(remote), util.ShellQuote(local))
+		if _, err := util.Exec(cmd, false, nil); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, db.Name), nil
+}
+
+func databaseBase(name string) string {
+	for _, suffix := range databaseSuffixes {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			return base
+		}
+	}
+	return name
+}
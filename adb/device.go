@@ -0,0 +1,53 @@
+package adb
+
+import (
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+// GetProp returns a trimmed system property.
+func GetProp(name string) string {
+	output, _ := util.Exec("adb shell getprop "+name, true, nil)
+	return strings.TrimSpace(output)
+}
+
+// GetABIList returns the supported ABIs of the device, preferred first.
+func GetABIList() []string {
+	var abis []string
+	for _, abi := range strings.Split(GetProp("ro.product.cpu.abilist"), ",") {
+		if abi = strings.TrimSpace(abi); abi != "" {
+			abis = append(abis, abi)
+		}
+	}
+	if len(abis) == 0 {
+		if abi := GetProp("ro.product.cpu.abi"); abi != "" {
+			abis = append(abis, abi)
+		}
+	}
+	return abis
+}
+
+// GetDensity returns the effective screen density in dpi, the override density wins.
+func GetDensity() int {
+	output, _ := util.Exec("adb shell wm density", true, nil)
+	density := 0
+	for _, line := range util.MultiLine(output) {
+		idx := strings.Index(line, ":")
+		if idx == -1 {
+			continue
+		}
+		if d, err := strconv.Atoi(strings.TrimSpace(line[idx+1:])); err == nil {
+			// "Override density" is printed after "Physical density"
+			density = d
+		}
+	}
+	return density
+}
+
+// GetSDKVersion returns ro.build.version.sdk, or 0 when unknown.
+func GetSDKVersion() int {
+	sdk, _ := strconv.Atoi(GetProp("ro.build.version.sdk"))
+	return sdk
+}
@@ -0,0 +1,42 @@
+package adb
+
+import (
+	"regexp"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+var keyValueRe = regexp.MustCompile(`(\w+)=(\S*)`)
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// indentedBlock returns the lines following lines[i] that are indented deeper than it.
+func indentedBlock(lines []string, i int) []string {
+	base := indentOf(lines[i])
+	end := i + 1
+	for end < len(lines) && indentOf(lines[end]) > base {
+		end++
+	}
+	return lines[i+1 : end]
+}
+
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, l := range util.MultiLine(output) {
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func keyValues(s string) map[string]string {
+	kv := make(map[string]string)
+	for _, m := range keyValueRe.FindAllStringSubmatch(s, -1) {
+		kv[m[1]] = strings.TrimSuffix(m[2], ")")
+	}
+	return kv
+}
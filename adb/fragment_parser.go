@@ -0,0 +1,412 @@
+package adb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+// fragmentStates is the lifecycle state numbering of one fragment implementation.
+type fragmentStates struct {
+	names   map[int]string
+	resumed int
+}
+
+var (
+	// androidx.fragment 1.3+, the dump prints headers as "HomeFragment{3c1d2a} (...)"
+	androidxStates = &fragmentStates{resumed: 7, names: map[int]string{
+		-1: "INITIALIZING",
+		0:  "ATTACHED",
+		1:  "CREATED",
+		2:  "VIEW_CREATED",
+		3:  "AWAITING_EXIT_EFFECTS",
+		4:  "ACTIVITY_CREATED",
+		5:  "STARTED",
+		6:  "AWAITING_ENTER_EFFECTS",
+		7:  "RESUMED",
+	}}
+	// support library 28 and androidx.fragment before 1.3, dumped under "Local FragmentActivity"
+	supportStates = &fragmentStates{resumed: 4, names: map[int]string{
+		0: "INITIALIZING",
+		1: "CREATED",
+		2: "ACTIVITY_CREATED",
+		3: "STARTED",
+		4: "RESUMED",
+	}}
+	// android.app.Fragment and support library 27 and older, which still had STOPPED
+	frameworkStates = &fragmentStates{resumed: 5, names: map[int]string{
+		0: "INITIALIZING",
+		1: "CREATED",
+		2: "ACTIVITY_CREATED",
+		3: "STOPPED",
+		4: "STARTED",
+		5: "RESUMED",
+	}}
+)
+
+var (
+	activityHeaderRe = regexp.MustCompile(`^ACTIVITY (\S+) (\S+) pid=(\S+)`)
+	// androidx:                 "HomeFragment{3c1d2a} (5f0e8b2c-... id=0x7f0a0001 tag=home)"
+	// framework/support library: "#0: HomeFragment{3c1d2a #0 id=0x7f0a0001 home}"
+	fragmentHeaderRe  = regexp.MustCompile(`^(?:#\d+: )?([\w$.]+)\{([0-9a-f]+)(?: ([^}]*))?\}(?: \((.*)\))?`)
+	backStackHeaderRe = regexp.MustCompile(`^#(\d+): BackStackEntry\{([0-9a-f]+)(?: #(\d+))?(?: ([^}]*))?\}`)
+)
+
+// ActivityFragments is the fragment dump of a single activity record.
+type ActivityFragments struct {
+	Component string
+	Token     string
+	Pid       string
+	Resumed   bool
+	Managers  []*FragmentManagerDump
+}
+
+// FragmentManagerDump is one FragmentManager with its fragments and back stack.
+type FragmentManagerDump struct {
+	Fragments []*Fragment
+	Added     []string
+	BackStack []*BackStackEntry
+	CurState  int
+}
+
+// Fragment is a single fragment parsed from the FragmentManager dump.
+type Fragment struct {
+	Name        string
+	Hash        string
+	Who         string
+	ID          string
+	Tag         string
+	ContainerID string
+	State       int
+	Added       bool
+	Hidden      bool
+	Detached    bool
+	Removing    bool
+	UserVisible bool
+	Resumed     bool
+	Visible     bool
+	Children    *FragmentManagerDump
+	androidx    bool
+	states      *fragmentStates
+}
+
+// BackStackEntry is a FragmentManager back stack record.
+type BackStackEntry struct {
+	Index string
+	Hash  string
+	Name  string
+	Ops   []string
+}
+
+// StateName returns the lifecycle state name of the fragment.
+func (f *Fragment) StateName() string {
+	states := f.states
+	if states == nil {
+		states = androidxStates
+	}
+	if name, ok := states.names[f.State]; ok {
+		return name
+	}
+	return strconv.Itoa(f.State)
+}
+
+// GetFragmentDump returns the raw `dumpsys activity <pkg>` output.
+func GetFragmentDump(packageName string) (string, error) {
+	return util.Exec(fmt.Sprintf("adb shell dumpsys activity %s", packageName), false, nil)
+}
+
+// TryGetFragmentDump is GetFragmentDump for polling loops, adb errors do not exit.
+// Exec hands back adb's stderr as the output, a dump without any ACTIVITY record is an error.
+func TryGetFragmentDump(packageName string) (string, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell dumpsys activity %s", packageName), true, nil)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range nonEmptyLines(output) {
+		if activityHeaderRe.MatchString(strings.TrimSpace(line)) {
+			return output, nil
+		}
+	}
+	return "", fmt.Errorf("no activity in the dump of %s: %s", packageName, strings.TrimSpace(output))
+}
+
+// ParseFragmentDump parses `dumpsys activity <pkg>` into per-activity fragment trees.
+func ParseFragmentDump(output string) []*ActivityFragments {
+	lines := nonEmptyLines(output)
+	var activities []*ActivityFragments
+
+	for i := 0; i < len(lines); i++ {
+		m := activityHeaderRe.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if m == nil {
+			continue
+		}
+		body := indentedBlock(lines, i)
+		activity := &ActivityFragments{Component: m[1], Token: m[2], Pid: m[3]}
+		parseActivityBody(activity, body)
+		activities = append(activities, activity)
+		i += len(body)
+	}
+	return activities
+}
+
+func parseActivityBody(activity *ActivityFragments, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	top := indentOf(lines[0])
+	var managerLines []string
+	// framework fragments are dumped under "Local Activity", support ones under "Local FragmentActivity"
+	states := frameworkStates
+
+	flush := func() {
+		if len(managerLines) > 0 {
+			manager := parseFragmentManager(managerLines)
+			applyStates(manager, states)
+			activity.Managers = append(activity.Managers, manager)
+			managerLines = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		if indentOf(lines[i]) != top {
+			continue
+		}
+		trimmed := strings.TrimSpace(lines[i])
+		block := indentedBlock(lines, i)
+
+		switch {
+		case strings.HasPrefix(trimmed, "Local ") && strings.HasSuffix(trimmed, "State:"):
+			flush()
+			states = frameworkStates
+			if strings.HasPrefix(trimmed, "Local FragmentActivity") {
+				states = supportStates
+			}
+			for _, l := range block {
+				if v, ok := keyValues(l)["mResumed"]; ok {
+					activity.Resumed = v == "true"
+				}
+			}
+		case strings.HasPrefix(trimmed, "Active Fragments"):
+			// A new FragmentManager dump starts with its active fragments.
+			flush()
+			managerLines = append(managerLines, lines[i:i+1+len(block)]...)
+		case isFragmentManagerSection(trimmed), len(managerLines) > 0 && fragmentHeaderRe.MatchString(trimmed):
+			managerLines = append(managerLines, lines[i:i+1+len(block)]...)
+			if strings.HasPrefix(trimmed, "FragmentManager misc state") {
+				flush()
+			}
+		}
+		i += len(block)
+	}
+	flush()
+
+	for _, manager := range activity.Managers {
+		markVisibility(manager, activity.Resumed)
+	}
+}
+
+func isFragmentManagerSection(trimmed string) bool {
+	for _, prefix := range []string{"Added Fragments", "Back Stack", "FragmentManager misc state"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseFragmentManager(lines []string) *FragmentManagerDump {
+	manager := &FragmentManagerDump{CurState: -1}
+	if len(lines) == 0 {
+		return manager
+	}
+	top := indentOf(lines[0])
+	section := ""
+
+	for i := 0; i < len(lines); i++ {
+		if indentOf(lines[i]) != top {
+			continue
+		}
+		trimmed := strings.TrimSpace(lines[i])
+		block := indentedBlock(lines, i)
+
+		switch {
+		case strings.HasPrefix(trimmed, "Active Fragments"):
+			section = "active"
+			// Legacy managers nest "#0: Fragment{...}" entries under the header.
+			parseFragmentEntries(manager, block)
+		case strings.HasPrefix(trimmed, "Added Fragments"):
+			section = "added"
+			for _, l := range block {
+				if m := fragmentHeaderRe.FindStringSubmatch(strings.TrimSpace(l)); m != nil {
+					manager.Added = append(manager.Added, m[2])
+				}
+			}
+		case strings.HasPrefix(trimmed, "Back Stack Indices"):
+			section = ""
+		case strings.HasPrefix(trimmed, "Back Stack"):
+			section = "backstack"
+			manager.BackStack = parseBackStack(block)
+		case strings.HasPrefix(trimmed, "FragmentManager misc state"):
+			section = ""
+			for _, l := range block {
+				if v, ok := keyValues(l)["mCurState"]; ok {
+					if n, err := strconv.Atoi(v); err == nil {
+						manager.CurState = n
+					}
+				}
+			}
+		case section == "active":
+			// androidx prints fragments at the same indent as the header.
+			if f := parseFragment(trimmed, block); f != nil {
+				manager.Fragments = append(manager.Fragments, f)
+			}
+		}
+		i += len(block)
+	}
+	return manager
+}
+
+func parseFragmentEntries(manager *FragmentManagerDump, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	top := indentOf(lines[0])
+	for i := 0; i < len(lines); i++ {
+		if indentOf(lines[i]) != top {
+			continue
+		}
+		block := indentedBlock(lines, i)
+		if f := parseFragment(strings.TrimSpace(lines[i]), block); f != nil {
+			manager.Fragments = append(manager.Fragments, f)
+		}
+		i += len(block)
+	}
+}
+
+func parseFragment(header string, lines []string) *Fragment {
+	m := fragmentHeaderRe.FindStringSubmatch(header)
+	if m == nil {
+		return nil
+	}
+	f := &Fragment{Name: m[1], Hash: m[2], State: -1, UserVisible: true}
+	if m[3] != "" {
+		// legacy "#<index> id=0x<id> <tag>", the tag is printed without a key
+		fields := strings.Fields(m[3])
+		for i, field := range fields {
+			if strings.HasPrefix(field, "id=") {
+				f.ID = strings.TrimPrefix(field, "id=")
+			} else if !strings.HasPrefix(field, "#") {
+				f.Tag = strings.Join(fields[i:], " ")
+				break
+			}
+		}
+	}
+	if m[4] != "" {
+		f.androidx = true
+		fields := strings.Fields(m[4])
+		if len(fields) > 0 && !strings.Contains(fields[0], "=") {
+			f.Who = fields[0]
+		}
+		kv := keyValues(m[4])
+		f.ID = kv["id"]
+		f.Tag = kv["tag"]
+	}
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "Child FragmentManager") {
+			block := indentedBlock(lines, i)
+			f.Children = parseFragmentManager(block)
+			i += len(block)
+			continue
+		}
+		kv := keyValues(trimmed)
+		for key, value := range kv {
+			switch key {
+			case "mContainerId":
+				f.ContainerID = value
+			case "mTag":
+				if f.Tag == "" && value != "null" {
+					f.Tag = value
+				}
+			case "mFragmentId":
+				if f.ID == "" && value != "#0" {
+					f.ID = value
+				}
+			case "mState":
+				if n, err := strconv.Atoi(value); err == nil {
+					f.State = n
+				}
+			case "mAdded":
+				f.Added = value == "true"
+			case "mHidden":
+				f.Hidden = value == "true"
+			case "mDetached":
+				f.Detached = value == "true"
+			case "mRemoving":
+				f.Removing = value == "true"
+			case "mUserVisibleHint":
+				f.UserVisible = value == "true"
+			}
+		}
+	}
+	return f
+}
+
+func parseBackStack(lines []string) []*BackStackEntry {
+	var entries []*BackStackEntry
+	var current *BackStackEntry
+	for _, l := range lines {
+		trimmed := strings.TrimSpace(l)
+		if m := backStackHeaderRe.FindStringSubmatch(trimmed); m != nil {
+			current = &BackStackEntry{Index: m[1], Hash: m[2], Name: strings.TrimSpace(m[4])}
+			entries = append(entries, current)
+			continue
+		}
+		if current != nil && strings.HasPrefix(trimmed, "Op #") {
+			if idx := strings.Index(trimmed, ":"); idx != -1 {
+				current.Ops = append(current.Ops, strings.TrimSpace(trimmed[idx+1:]))
+			}
+		}
+	}
+	return entries
+}
+
+// applyStates sets the state numbering of the fragments of manager and its child managers.
+// androidx 1.3+ headers identify themselves, older ones use the numbering of the section they
+// were dumped in. Support library 27 and older still numbered like the framework, a manager of
+// such a FragmentActivity reports mCurState=5 once resumed.
+func applyStates(manager *FragmentManagerDump, states *fragmentStates) {
+	if manager == nil {
+		return
+	}
+	if states == supportStates && manager.CurState == frameworkStates.resumed {
+		states = frameworkStates
+	}
+	for _, f := range manager.Fragments {
+		f.states = states
+		if f.androidx {
+			f.states = androidxStates
+		}
+		applyStates(f.Children, f.states)
+	}
+}
+
+// markVisibility flags the fragments that are resumed and shown to the user.
+func markVisibility(manager *FragmentManagerDump, parentVisible bool) {
+	if manager == nil {
+		return
+	}
+	for _, f := range manager.Fragments {
+		if manager.CurState >= 0 {
+			f.Resumed = parentVisible && f.State >= manager.CurState
+		} else {
+			f.Resumed = parentVisible && f.states != nil && f.State == f.states.resumed
+		}
+		f.Visible = f.Resumed && f.Added && !f.Hidden && !f.Detached && !f.Removing && f.UserVisible
+		markVisibility(f.Children, f.Visible)
+	}
+}
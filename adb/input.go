@@ -0,0 +1,158 @@
+package adb
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rabbit-go/util"
+)
+
+const adbKeyboardIME = "com.android.adbkeyboard/.AdbIME"
+
+var keyNames = map[string]string{
+	"back":         "KEYCODE_BACK",
+	"home":         "KEYCODE_HOME",
+	"recents":      "KEYCODE_APP_SWITCH",
+	"menu":         "KEYCODE_MENU",
+	"power":        "KEYCODE_POWER",
+	"wakeup":       "KEYCODE_WAKEUP",
+	"sleep":        "KEYCODE_SLEEP",
+	"volume-up":    "KEYCODE_VOLUME_UP",
+	"volume-down":  "KEYCODE_VOLUME_DOWN",
+	"mute":         "KEYCODE_VOLUME_MUTE",
+	"enter":        "KEYCODE_ENTER",
+	"delete":       "KEYCODE_DEL",
+	"del":          "KEYCODE_DEL",
+	"tab":          "KEYCODE_TAB",
+	"escape":       "KEYCODE_ESCAPE",
+	"search":       "KEYCODE_SEARCH",
+	"camera":       "KEYCODE_CAMERA",
+	"up":           "KEYCODE_DPAD_UP",
+	"down":         "KEYCODE_DPAD_DOWN",
+	"left":         "KEYCODE_DPAD_LEFT",
+	"right":        "KEYCODE_DPAD_RIGHT",
+	"center":       "KEYCODE_DPAD_CENTER",
+	"notification": "KEYCODE_NOTIFICATION",
+}
+
+// KeyNames returns the supported key event names.
+func KeyNames() []string {
+	names := make([]string, 0, len(keyNames))
+	for name := range keyNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// KeyCode maps a key name, a KEYCODE_* constant or a number to an `input keyevent` argument.
+func KeyCode(name string) (string, error) {
+	if code, ok := keyNames[strings.ToLower(name)]; ok {
+		return code, nil
+	}
+	upper := strings.ToUpper(name)
+	if strings.HasPrefix(upper, "KEYCODE_") {
+		return upper, nil
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown key: %s", name)
+}
+
+// Tap taps the screen at x, y.
+func Tap(x, y int) error {
+	_, err := util.Exec(fmt.Sprintf("adb shell input tap %d %d", x, y), false, nil)
+	return err
+}
+
+// Swipe swipes from x1, y1 to x2, y2 in the given duration.
+func Swipe(x1, y1, x2, y2 int, duration time.Duration) error {
+	cmd := fmt.Sprintf("adb shell input swipe %d %d %d %d %d", x1, y1, x2, y2, duration.Milliseconds())
+	_, err := util.Exec(cmd, false, nil)
+	return err
+}
+
+// Drag drags from x1, y1 to x2, y2, holding the start point first like a real drag and drop.
+func Drag(x1, y1, x2, y2 int, duration time.Duration) error {
+	cmd := fmt.Sprintf("adb shell input draganddrop %d %d %d %d %d", x1, y1, x2, y2, duration.Milliseconds())
+	output, err := util.Exec(cmd, true, nil)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(output, "Error") || strings.Contains(output, "Unknown command") {
+		// draganddrop is missing before Android 11, a slow swipe is the closest equivalent
+		return Swipe(x1, y1, x2, y2, duration)
+	}
+	return nil
+}
+
+// LongPress presses x, y for the given duration.
+func LongPress(x, y int, duration time.Duration) error {
+	return Swipe(x, y, x, y, duration)
+}
+
+// KeyEvent sends a key event, optionally as a long press.
+func KeyEvent(code string, long bool) error {
+	cmd := fmt.Sprintf("adb shell input keyevent %s", code)
+	if long {
+		cmd = fmt.Sprintf("adb shell input keyevent --longpress %s", code)
+	}
+	_, err := util.Exec(cmd, false, nil)
+	return err
+}
+
+// InputText types text into the focused field. `input text` only supports ASCII,
+// other text is sent through the ADBKeyBoard IME when it is installed.
+func InputText(text string) error {
+	if isASCII(text) {
+		cmd := fmt.Sprintf("adb shell input text %s", QuoteRemote(EscapeInputText(text)))
+		_, err := util.Exec(cmd, false, nil)
+		return err
+	}
+
+	imes, err := util.Exec("adb shell ime list -s", true, nil)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(imes, adbKeyboardIME) {
+		return fmt.Errorf("non ascii text needs ADBKeyBoard (%s), install it from https://github.com/senzhk/ADBKeyBoard", adbKeyboardIME)
+	}
+	current, _ := util.Exec("adb shell settings get secure default_input_method", true, nil)
+	current = strings.TrimSpace(current)
+	if current != adbKeyboardIME {
+		if _, err := util.Exec("adb shell ime set "+adbKeyboardIME, false, nil); err != nil {
+			return err
+		}
+		// the IME needs a moment to bind to the focused field before and after switching
+		time.Sleep(500 * time.Millisecond)
+		defer func() {
+			time.Sleep(500 * time.Millisecond)
+			if current != "" && current != "null" {
+				_, _ = util.Exec("adb shell ime set "+QuoteRemote(current), true, nil)
+			}
+		}()
+	}
+
+	msg := base64.StdEncoding.EncodeToString([]byte(text))
+	_, err = util.Exec(fmt.Sprintf("adb shell am broadcast -a ADB_INPUT_B64 --es msg %s", msg), false, nil)
+	return err
+}
+
+// EscapeInputText encodes spaces the way `input text` expects them.
+func EscapeInputText(text string) string {
+	return strings.ReplaceAll(text, " ", "%s")
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > 127 {
+			return false
+		}
+	}
+	return true
+}
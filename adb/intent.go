@@ -0,0 +1,260 @@
+package adb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+// Extra types supported by `am`, mapped to their command line option.
+var extraOptions = map[string]string{
+	"string":       "--es",
+	"int":          "--ei",
+	"bool":         "--ez",
+	"long":         "--el",
+	"float":        "--ef",
+	"string-array": "--esa",
+}
+
+var intentFlags = map[string]int{
+	"FLAG_GRANT_READ_URI_PERMISSION":     0x00000001,
+	"FLAG_GRANT_WRITE_URI_PERMISSION":    0x00000002,
+	"FLAG_INCLUDE_STOPPED_PACKAGES":      0x00000020,
+	"FLAG_ACTIVITY_CLEAR_TASK":           0x00008000,
+	"FLAG_ACTIVITY_NO_ANIMATION":         0x00010000,
+	"FLAG_ACTIVITY_REORDER_TO_FRONT":     0x00020000,
+	"FLAG_ACTIVITY_NO_USER_ACTION":       0x00040000,
+	"FLAG_ACTIVITY_RESET_TASK_IF_NEEDED": 0x00200000,
+	"FLAG_ACTIVITY_EXCLUDE_FROM_RECENTS": 0x00800000,
+	"FLAG_ACTIVITY_PREVIOUS_IS_TOP":      0x01000000,
+	"FLAG_ACTIVITY_CLEAR_TOP":            0x04000000,
+	"FLAG_ACTIVITY_MULTIPLE_TASK":        0x08000000,
+	"FLAG_ACTIVITY_NEW_TASK":             0x10000000,
+	"FLAG_RECEIVER_FOREGROUND":           0x10000000,
+	"FLAG_ACTIVITY_SINGLE_TOP":           0x20000000,
+	"FLAG_ACTIVITY_NO_HISTORY":           0x40000000,
+}
+
+// IntentExtra is a typed extra passed to `am`.
+type IntentExtra struct {
+	Type  string
+	Key   string
+	Value string
+}
+
+// Intent describes an intent sent through `am start`, `am startservice` or `am broadcast`.
+type Intent struct {
+	Component  string
+	Action     string
+	Data       string
+	MimeType   string
+	Package    string
+	Categories []string
+	Flags      []string
+	Extras     []IntentExtra
+}
+
+// ParseExtra parses a "key=value" pair into an extra of the given type.
+func ParseExtra(extraType, pair string) (IntentExtra, error) {
+	key, value, ok := strings.Cut(pair, "=")
+	if !ok || key == "" {
+		return IntentExtra{}, fmt.Errorf("invalid %s extra %q, expected key=value", extraType, pair)
+	}
+	return IntentExtra{Type: extraType, Key: key, Value: value}, nil
+}
+
+// LoadExtras reads extras from a JSON object. Values are typed by their JSON type,
+// or explicitly with {"type": "long", "value": 1}.
+func LoadExtras(path string) ([]IntentExtra, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var extras []IntentExtra
+	for _, key := range keys {
+		extra, err := jsonExtra(key, raw[key])
+		if err != nil {
+			return nil, err
+		}
+		extras = append(extras, extra)
+	}
+	return extras, nil
+}
+
+func jsonExtra(key string, value any) (IntentExtra, error) {
+	switch v := value.(type) {
+	case string:
+		return IntentExtra{Type: "string", Key: key, Value: v}, nil
+	case bool:
+		return IntentExtra{Type: "bool", Key: key, Value: strconv.FormatBool(v)}, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return IntentExtra{Type: "float", Key: key, Value: strconv.FormatFloat(v, 'f', -1, 64)}, nil
+		}
+		if v > math.MaxInt32 || v < math.MinInt32 {
+			return IntentExtra{Type: "long", Key: key, Value: strconv.FormatInt(int64(v), 10)}, nil
+		}
+		return IntentExtra{Type: "int", Key: key, Value: strconv.FormatInt(int64(v), 10)}, nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return IntentExtra{}, fmt.Errorf("extra %s: only string arrays are supported", key)
+			}
+			// am splits string arrays on unescaped commas
+			items = append(items, strings.ReplaceAll(s, ",", `\,`))
+		}
+		return IntentExtra{Type: "string-array", Key: key, Value: strings.Join(items, ",")}, nil
+	case map[string]any:
+		extraType, _ := v["type"].(string)
+		if _, ok := extraOptions[extraType]; !ok {
+			return IntentExtra{}, fmt.Errorf("extra %s: unknown type %q", key, extraType)
+		}
+		typed, err := jsonExtra(key, v["value"])
+		if err != nil {
+			return IntentExtra{}, err
+		}
+		typed.Type = extraType
+		return typed, nil
+	}
+	return IntentExtra{}, fmt.Errorf("extra %s: unsupported value %v", key, value)
+}
+
+// FlagsValue combines flag names (with or without the FLAG_ACTIVITY_ prefix) and hex values.
+func (i *Intent) FlagsValue() (int, error) {
+	value := 0
+	for _, flag := range i.Flags {
+		name := strings.ToUpper(flag)
+		if strings.HasPrefix(name, "0X") {
+			n, err := strconv.ParseInt(name[2:], 16, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid intent flag: %s", flag)
+			}
+			value |= int(n)
+			continue
+		}
+		n, ok := intentFlags[name]
+		if !ok {
+			n, ok = intentFlags["FLAG_ACTIVITY_"+name]
+		}
+		if !ok {
+			n, ok = intentFlags["FLAG_"+name]
+		}
+		if !ok {
+			return 0, fmt.Errorf("unknown intent flag: %s", flag)
+		}
+		value |= n
+	}
+	return value, nil
+}
+
+// Args returns the intent arguments shared by `am` and `cmd package resolve-activity`.
+func (i *Intent) Args() (string, error) {
+	var args []string
+	if i.Action != "" {
+		args = append(args, "-a", QuoteRemote(i.Action))
+	}
+	if i.Data != "" {
+		args = append(args, "-d", QuoteRemote(i.Data))
+	}
+	if i.MimeType != "" {
+		args = append(args, "-t", QuoteRemote(i.MimeType))
+	}
+	for _, category := range i.Categories {
+		args = append(args, "-c", QuoteRemote(category))
+	}
+	if i.Component != "" {
+		args = append(args, "-n", QuoteRemote(i.Component))
+	}
+	if i.Package != "" && i.Component == "" {
+		args = append(args, "-p", QuoteRemote(i.Package))
+	}
+
+	flags, err := i.FlagsValue()
+	if err != nil {
+		return "", err
+	}
+	if flags != 0 {
+		args = append(args, "-f", fmt.Sprintf("0x%08x", flags))
+	}
+
+	for _, extra := range i.Extras {
+		option, ok := extraOptions[extra.Type]
+		if !ok {
+			return "", fmt.Errorf("unknown extra type: %s", extra.Type)
+		}
+		args = append(args, option, QuoteRemote(extra.Key), QuoteRemote(extra.Value))
+	}
+	return strings.Join(args, " "), nil
+}
+
+// ResolveIntent returns the components matching the intent for the given target
+// (activity, service or broadcast) according to the installed intent filters.
+func ResolveIntent(target string, intent *Intent) ([]string, error) {
+	query := map[string]string{
+		"activity":  "query-activities",
+		"service":   "query-services",
+		"broadcast": "query-receivers",
+	}[target]
+	if query == "" {
+		return nil, fmt.Errorf("unknown intent target: %s", target)
+	}
+
+	args, err := intent.Args()
+	if err != nil {
+		return nil, err
+	}
+	output, err := util.Exec(fmt.Sprintf("adb shell cmd package %s --brief %s", query, args), true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var components []string
+	for _, line := range util.MultiLine(output) {
+		line = strings.TrimSpace(line)
+		if strings.Contains(line, "/") && !strings.Contains(line, "=") && !strings.Contains(line, " ") {
+			components = append(components, line)
+		}
+	}
+	return components, nil
+}
+
+// ResolveActivity returns the single activity the intent resolves to, which is the
+// chooser when several activities match.
+func ResolveActivity(intent *Intent) (string, error) {
+	args, err := intent.Args()
+	if err != nil {
+		return "", err
+	}
+	output, err := util.Exec(fmt.Sprintf("adb shell cmd package resolve-activity --brief %s", args), true, nil)
+	if err != nil {
+		return "", err
+	}
+	lines := nonEmptyLines(output)
+	if len(lines) == 0 {
+		return "", nil
+	}
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if !strings.Contains(last, "/") {
+		return "", nil
+	}
+	return last, nil
+}
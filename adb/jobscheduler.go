@@ -0,0 +1,149 @@
+package adb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+var (
+	// "JOB #u0a123/5: 1a2b3c com.example/androidx.work.impl.background.systemjob.SystemJobService"
+	jobHeaderRe = regexp.MustCompile(`^JOB #(\S+)/(-?\d+): \S+ (\S+)`)
+	// "-1h2m3s456ms   START: #u0a123/5 com.example/.SyncJobService"
+	jobHistoryRe = regexp.MustCompile(`^(-\S+)\s+START: #(\S+)/(-?\d+)`)
+	jobRefRe     = regexp.MustCompile(`#(\S+)/(-?\d+)`)
+)
+
+// Job is a JobScheduler job of a package, WorkManager schedules its work as such jobs.
+type Job struct {
+	ID          int      `json:"id"`
+	UID         string   `json:"uid"`
+	Service     string   `json:"service"`
+	Interval    string   `json:"interval,omitempty"`
+	Constraints []string `json:"constraints"`
+	Unsatisfied []string `json:"unsatisfied"`
+	LastRun     string   `json:"lastRun,omitempty"`
+	NextRun     string   `json:"nextRun,omitempty"`
+	Ready       bool     `json:"ready"`
+	State       string   `json:"state,omitempty"`
+}
+
+func (j *Job) key() string {
+	return j.UID + "/" + strconv.Itoa(j.ID)
+}
+
+// GetJobs returns the jobs scheduled by a package, by every package when it is empty.
+func GetJobs(packageName string) ([]*Job, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell dumpsys jobscheduler %s", packageName), false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJobs(output), nil
+}
+
+// ParseJobs parses the registered jobs of `dumpsys jobscheduler`, together with the
+// active jobs, the pending queue and the job history that refer to them.
+func ParseJobs(output string) []*Job {
+	lines := nonEmptyLines(output)
+	var jobs []*Job
+	byKey := make(map[string]*Job)
+	lastStart := make(map[string]string)
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if m := jobHeaderRe.FindStringSubmatch(trimmed); m != nil {
+			id, _ := strconv.Atoi(m[2])
+			job := &Job{ID: id, UID: m[1], Service: m[3]}
+			parseJobBlock(job, indentedBlock(lines, i))
+			if _, ok := byKey[job.key()]; !ok {
+				byKey[job.key()] = job
+				jobs = append(jobs, job)
+			}
+			continue
+		}
+		if m := jobHistoryRe.FindStringSubmatch(trimmed); m != nil {
+			lastStart[m[2]+"/"+m[3]] = m[1]
+			continue
+		}
+
+		state := map[string]string{"Active jobs:": "running", "Pending queue:": "pending"}[trimmed]
+		if state == "" {
+			continue
+		}
+		for _, l := range indentedBlock(lines, i) {
+			if m := jobRefRe.FindStringSubmatch(l); m != nil {
+				if job, ok := byKey[m[1]+"/"+m[2]]; ok && job.State == "" {
+					job.State = state
+				}
+			}
+		}
+	}
+
+	for _, job := range jobs {
+		if job.LastRun == "" {
+			job.LastRun = lastStart[job.key()]
+		}
+	}
+	return jobs
+}
+
+func parseJobBlock(job *Job, block []string) {
+	for _, line := range block {
+		line = strings.TrimSpace(line)
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "PERIODIC":
+			job.Interval = keyValues(value)["interval"]
+		case "Required constraints":
+			job.Constraints = jobConstraints(value)
+		case "Unsatisfied constraints":
+			job.Unsatisfied = jobConstraints(value)
+		case "Last successful run":
+			job.LastRun = value
+		case "Run time":
+			if earliest := strings.TrimSuffix(keyValues(value)["earliest"], ","); earliest != "none" {
+				job.NextRun = earliest
+			}
+		case "Ready":
+			job.Ready = strings.HasPrefix(value, "true")
+		}
+	}
+}
+
+// jobConstraints drops the "[0x90000000]" mask that follows the constraint names.
+func jobConstraints(value string) []string {
+	constraints := []string{}
+	for _, f := range strings.Fields(value) {
+		if !strings.HasPrefix(f, "[") {
+			constraints = append(constraints, f)
+		}
+	}
+	return constraints
+}
+
+// RunJob runs a scheduled job now, force ignores its constraints.
+func RunJob(packageName string, id int, force bool, user string) error {
+	cmd := "adb shell cmd jobscheduler run"
+	if force {
+		cmd += " -f"
+	}
+	if user != "" {
+		cmd += " -u " + QuoteRemote(user)
+	}
+	output, err := util.Exec(fmt.Sprintf("%s %s %d 2>&1", cmd, packageName, id), true, nil)
+	if err != nil {
+		return err
+	}
+	output = strings.TrimSpace(output)
+	if !strings.HasPrefix(output, "Running job") {
+		return fmt.Errorf("cannot run job %d of %s: %s", id, packageName, output)
+	}
+	return nil
+}
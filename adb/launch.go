@@ -0,0 +1,120 @@
+package adb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+// "Displayed com.example/.MainActivity: +512ms", Android 13+ adds " for user 0" before the colon
+var displayedRe = regexp.MustCompile(`Displayed (\S+)(?: for user \d+)?: \+((?:\d+s)?\d+ms)`)
+
+// LaunchResult is the output of `am start -W`.
+type LaunchResult struct {
+	Status      string
+	LaunchState string
+	Activity    string
+	TotalTime   int
+	WaitTime    int
+}
+
+// ResolveLauncherActivity returns the launcher component of a package.
+func ResolveLauncherActivity(packageName string) (string, error) {
+	cmd := fmt.Sprintf("adb shell cmd package resolve-activity --brief -c android.intent.category.LAUNCHER %s", packageName)
+	output, err := util.Exec(cmd, false, nil)
+	if err != nil {
+		return "", err
+	}
+	lines := nonEmptyLines(output)
+	if len(lines) == 0 {
+		return "", fmt.Errorf("cannot resolve launcher activity of %s", packageName)
+	}
+	component := strings.TrimSpace(lines[len(lines)-1])
+	if !strings.Contains(component, "/") {
+		return "", fmt.Errorf("cannot resolve launcher activity of %s: %s", packageName, component)
+	}
+	return component, nil
+}
+
+// StartActivityAndWait runs `am start -W` with extra am options and parses the reported times.
+func StartActivityAndWait(component string, options ...string) (*LaunchResult, error) {
+	cmd := fmt.Sprintf("adb shell am start -W -n %s", component)
+	if len(options) > 0 {
+		cmd += " " + strings.Join(options, " ")
+	}
+	output, err := util.Exec(cmd, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := ParseLaunchResult(output)
+	if strings.Contains(output, "Error") {
+		return result, fmt.Errorf("%s", strings.TrimSpace(output))
+	}
+	return result, nil
+}
+
+// ParseLaunchResult parses the key/value lines printed by `am start -W`.
+func ParseLaunchResult(output string) *LaunchResult {
+	result := &LaunchResult{TotalTime: -1, WaitTime: -1}
+	for _, line := range util.MultiLine(output) {
+		idx := strings.Index(line, ":")
+		if idx == -1 {
+			continue
+		}
+		key := strings.TrimSpace(line[:idx])
+		value := strings.TrimSpace(line[idx+1:])
+		switch key {
+		case "Status":
+			result.Status = value
+		case "LaunchState":
+			result.LaunchState = value
+		case "Activity":
+			result.Activity = value
+		case "TotalTime":
+			result.TotalTime, _ = strconv.Atoi(value)
+		case "WaitTime":
+			result.WaitTime, _ = strconv.Atoi(value)
+		}
+	}
+	return result
+}
+
+// ParseDisplayedTime returns the "Displayed" time in ms of the last launch of component found in logcat.
+func ParseDisplayedTime(logcat, component string) int {
+	displayed := -1
+	for _, m := range displayedRe.FindAllStringSubmatch(logcat, -1) {
+		if component != "" && !sameComponent(m[1], component) {
+			continue
+		}
+		displayed = parseDisplayedDuration(m[2])
+	}
+	return displayed
+}
+
+// parseDisplayedDuration converts "+1s234ms" style durations (without the plus) to ms.
+func parseDisplayedDuration(s string) int {
+	total := 0
+	if seconds, rest, ok := strings.Cut(s, "s"); ok && rest != "" {
+		n, _ := strconv.Atoi(seconds)
+		total += n * 1000
+		s = rest
+	}
+	ms, _ := strconv.Atoi(strings.TrimSuffix(s, "ms"))
+	return total + ms
+}
+
+// sameComponent compares components written either as pkg/.Activity or pkg/pkg.Activity.
+func sameComponent(a, b string) bool {
+	return expandComponent(a) == expandComponent(b)
+}
+
+func expandComponent(component string) string {
+	parts := strings.SplitN(component, "/", 2)
+	if len(parts) == 2 && strings.HasPrefix(parts[1], ".") {
+		return parts[0] + "/" + parts[0] + parts[1]
+	}
+	return component
+}
@@ -0,0 +1,251 @@
+package adb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+var (
+	meminfoHeaderRe = regexp.MustCompile(`\*\* MEMINFO in pid (\d+) \[([^\]]+)\] \*\*`)
+	meminfoTotalRe  = regexp.MustCompile(`TOTAL( PSS| RSS| SWAP PSS)?:\s+(\d+)`)
+	meminfoObjectRe = regexp.MustCompile(`([A-Za-z][A-Za-z ]*?):\s+(\d+)`)
+)
+
+// memCategoryRows maps the App Summary categories to the rows of the detail table
+// used to sum up their private dirty memory.
+var memCategoryRows = map[string][]string{
+	"Java Heap":   {"Dalvik Heap", ".art mmap"},
+	"Native Heap": {"Native Heap"},
+	"Code":        {".so mmap", ".jar mmap", ".apk mmap", ".ttf mmap", ".dex mmap", ".oat mmap"},
+	"Stack":       {"Stack"},
+	"Graphics":    {"Gfx dev", "EGL mtrack", "GL mtrack"},
+}
+
+// MemCategory is an App Summary category of `dumpsys meminfo <pkg>`, sizes in KB.
+type MemCategory struct {
+	Name         string `json:"name"`
+	PSS          int    `json:"pss"`
+	RSS          int    `json:"rss,omitempty"`
+	PrivateDirty int    `json:"privateDirty"`
+}
+
+// MemRow is a row of the meminfo detail table keyed by column, e.g. "Pss Total".
+type MemRow struct {
+	Name   string         `json:"name"`
+	Values map[string]int `json:"values"`
+}
+
+// MemInfo is the parsed `dumpsys meminfo <pkg>` of a process.
+type MemInfo struct {
+	PID          int            `json:"pid"`
+	Process      string         `json:"process"`
+	TotalPSS     int            `json:"totalPss"`
+	TotalRSS     int            `json:"totalRss,omitempty"`
+	TotalSwapPSS int            `json:"totalSwapPss"`
+	Categories   []*MemCategory `json:"categories"`
+	Rows         []*MemRow      `json:"rows"`
+	Objects      map[string]int `json:"objects"`
+}
+
+// Category returns the App Summary category with the given name.
+func (m *MemInfo) Category(name string) *MemCategory {
+	for _, c := range m.Categories {
+		if c.Name == name {
+			return c
+		}
+	}
+	return &MemCategory{Name: name}
+}
+
+// GetMemInfo returns the meminfo of the main process of a package.
+func GetMemInfo(packageName string) (*MemInfo, error) {
+	return memInfo(packageName, false)
+}
+
+// TryGetMemInfo is GetMemInfo for sampling loops, adb errors are returned instead of exiting.
+func TryGetMemInfo(packageName string) (*MemInfo, error) {
+	return memInfo(packageName, true)
+}
+
+func memInfo(packageName string, ignoreError bool) (*MemInfo, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell dumpsys meminfo %s", packageName), ignoreError, nil)
+	if err != nil {
+		return nil, err
+	}
+	infos := ParseMemInfo(output)
+	for _, info := range infos {
+		if info.Process == packageName {
+			return info, nil
+		}
+	}
+	if len(infos) > 0 {
+		return infos[0], nil
+	}
+	return nil, fmt.Errorf("no meminfo of %s, is it running? %s", packageName, strings.TrimSpace(output))
+}
+
+// ParseMemInfo parses every "** MEMINFO in pid" section of `dumpsys meminfo`.
+func ParseMemInfo(output string) []*MemInfo {
+	var infos []*MemInfo
+	var current []string
+	var header []string
+	flush := func() {
+		if header != nil {
+			infos = append(infos, parseMemInfoSection(header, current))
+		}
+	}
+	for _, line := range util.MultiLine(output) {
+		if m := meminfoHeaderRe.FindStringSubmatch(line); m != nil {
+			flush()
+			header, current = m, nil
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return infos
+}
+
+func parseMemInfoSection(header []string, lines []string) *MemInfo {
+	info := &MemInfo{Process: header[2], Objects: make(map[string]int)}
+	info.PID, _ = strconv.Atoi(header[1])
+
+	section := "table"
+	var columns []string
+	var pssEnd, rssStart int
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch trimmed {
+		case "App Summary":
+			section = "summary"
+			continue
+		case "Objects":
+			section = "objects"
+			continue
+		case "SQL", "DATABASES", "Asset Allocations":
+			section = ""
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "---") {
+			continue
+		}
+
+		switch section {
+		case "table":
+			if columns == nil && i+1 < len(lines) {
+				columns = memTableColumns(line, lines[i+1])
+				continue
+			}
+			if row := parseMemRow(trimmed, columns); row != nil {
+				if row.Name == "TOTAL" {
+					section = ""
+				}
+				info.Rows = append(info.Rows, row)
+			}
+		case "summary":
+			if strings.HasPrefix(trimmed, "Pss(KB)") {
+				pssEnd = strings.Index(line, "Pss(KB)") + len("Pss(KB)")
+				rssStart = strings.Index(line, "Rss(KB)")
+				continue
+			}
+			if strings.HasPrefix(trimmed, "TOTAL") {
+				for _, m := range meminfoTotalRe.FindAllStringSubmatch(trimmed, -1) {
+					v, _ := strconv.Atoi(m[2])
+					switch m[1] {
+					case "", " PSS":
+						info.TotalPSS = v
+					case " RSS":
+						info.TotalRSS = v
+					case " SWAP PSS":
+						info.TotalSwapPSS = v
+					}
+				}
+				continue
+			}
+			if c := parseMemCategory(line, pssEnd, rssStart); c != nil {
+				info.Categories = append(info.Categories, c)
+			}
+		case "objects":
+			for _, m := range meminfoObjectRe.FindAllStringSubmatch(trimmed, -1) {
+				info.Objects[m[1]], _ = strconv.Atoi(m[2])
+			}
+		}
+	}
+
+	for _, c := range info.Categories {
+		for _, name := range memCategoryRows[c.Name] {
+			for _, row := range info.Rows {
+				if row.Name == name {
+					c.PrivateDirty += row.Values["Private Dirty"]
+				}
+			}
+		}
+	}
+	return info
+}
+
+// memTableColumns joins the two header lines, "Pss" over "Total" becomes "Pss Total".
+func memTableColumns(first, second string) []string {
+	top, bottom := strings.Fields(first), strings.Fields(second)
+	if len(top) != len(bottom) {
+		return bottom
+	}
+	columns := make([]string, len(top))
+	for i := range top {
+		columns[i] = top[i] + " " + bottom[i]
+	}
+	return columns
+}
+
+// parseMemRow parses "Native Heap    10468    10408 ...", trailing heap columns may be empty.
+func parseMemRow(line string, columns []string) *MemRow {
+	fields := strings.Fields(line)
+	first := len(fields)
+	for first > 0 {
+		if _, err := strconv.Atoi(fields[first-1]); err != nil {
+			break
+		}
+		first--
+	}
+	if first == 0 || first == len(fields) {
+		return nil
+	}
+	row := &MemRow{Name: strings.Join(fields[:first], " "), Values: make(map[string]int)}
+	for i, field := range fields[first:] {
+		if i < len(columns) {
+			row.Values[columns[i]], _ = strconv.Atoi(field)
+		}
+	}
+	return row
+}
+
+// parseMemCategory parses "Java Heap:     5000       10000", a single value belongs to
+// the column it is printed under, Unknown only has a Rss value on newer versions.
+func parseMemCategory(line string, pssEnd, rssStart int) *MemCategory {
+	name, rest, ok := strings.Cut(line, ":")
+	if !ok {
+		return nil
+	}
+	c := &MemCategory{Name: strings.TrimSpace(name)}
+	pos := len(name) + 1
+	for _, field := range strings.Fields(rest) {
+		idx := strings.Index(rest, field)
+		pos += idx
+		rest = rest[idx+len(field):]
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return nil
+		}
+		if rssStart > 0 && pos > (pssEnd+rssStart)/2 {
+			c.RSS = v
+		} else {
+			c.PSS = v
+		}
+		pos += len(field)
+	}
+	return c
+}
@@ -0,0 +1,185 @@
+package adb
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+var (
+	activeNetworkRe    = regexp.MustCompile(`Active default network: (\d+)`)
+	networkIDRe        = regexp.MustCompile(`network\{(\d+)\}`)
+	networkInfoRe      = regexp.MustCompile(`ni\{(\S+) (\S+)`)
+	networkTransportRe = regexp.MustCompile(`Transports: ([A-Z_|]+)`)
+	networkCapsRe      = regexp.MustCompile(`Capabilities: ([A-Z0-9_&]+)`)
+	networkIfaceRe     = regexp.MustCompile(`InterfaceName: (\S+)`)
+	networkAddrsRe     = regexp.MustCompile(`LinkAddresses: \[ ?([^\]]*)\]`)
+	networkDNSRe       = regexp.MustCompile(`DnsAddresses: \[ ?([^\]]*)\]`)
+)
+
+// Network is a connected network of `dumpsys connectivity`.
+type Network struct {
+	ID           int      `json:"id"`
+	Type         string   `json:"type"`
+	State        string   `json:"state,omitempty"`
+	Interface    string   `json:"interface,omitempty"`
+	Default      bool     `json:"default"`
+	Validated    bool     `json:"validated"`
+	Metered      bool     `json:"metered"`
+	Addresses    []string `json:"addresses"`
+	DNS          []string `json:"dns"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// GetNetworks returns the connected networks, the default network first.
+func GetNetworks() ([]*Network, error) {
+	output, err := util.Exec("adb shell dumpsys connectivity", false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNetworks(output), nil
+}
+
+// ParseNetworks parses the NetworkAgentInfo lines of `dumpsys connectivity`.
+func ParseNetworks(output string) []*Network {
+	active := -1
+	if m := activeNetworkRe.FindStringSubmatch(output); m != nil {
+		active, _ = strconv.Atoi(m[1])
+	}
+
+	var networks []*Network
+	seen := make(map[int]bool)
+	for _, line := range nonEmptyLines(output) {
+		if !strings.Contains(line, "NetworkAgentInfo") {
+			continue
+		}
+		m := networkIDRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		id, _ := strconv.Atoi(m[1])
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		network := &Network{ID: id, Default: id == active, Addresses: []string{}, DNS: []string{}, Capabilities: []string{}}
+		if m := networkInfoRe.FindStringSubmatch(line); m != nil {
+			network.Type, network.State = m[1], m[2]
+		}
+		if m := networkTransportRe.FindStringSubmatch(line); m != nil {
+			network.Type = strings.ReplaceAll(m[1], "|", ",")
+		}
+		if m := networkCapsRe.FindStringSubmatch(line); m != nil {
+			network.Capabilities = strings.Split(m[1], "&")
+		}
+		if m := networkIfaceRe.FindStringSubmatch(line); m != nil {
+			network.Interface = m[1]
+		}
+		if m := networkAddrsRe.FindStringSubmatch(line); m != nil {
+			network.Addresses = networkAddressList(m[1])
+		}
+		if m := networkDNSRe.FindStringSubmatch(line); m != nil {
+			network.DNS = networkAddressList(m[1])
+		}
+		// Android 9- prints the validation as lastValidated{true} instead of a capability
+		network.Validated = slices.Contains(network.Capabilities, "VALIDATED") || strings.Contains(line, "lastValidated{true}")
+		network.Metered = len(network.Capabilities) > 0 && !slices.Contains(network.Capabilities, "NOT_METERED")
+		networks = append(networks, network)
+	}
+
+	sort.SliceStable(networks, func(i, j int) bool {
+		return networks[i].Default && !networks[j].Default
+	})
+	return networks
+}
+
+// networkAddressList splits "fe80::1/64,192.168.1.5/24" or "/192.168.1.1 /8.8.8.8" into addresses.
+func networkAddressList(s string) []string {
+	addresses := []string{}
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if f = strings.TrimPrefix(f, "/"); f != "" {
+			addresses = append(addresses, f)
+		}
+	}
+	return addresses
+}
+
+// RadioStates returns whether wifi, mobile data and airplane mode are switched on.
+func RadioStates() (wifi, data, airplane bool) {
+	get := func(name string) bool {
+		output, _ := util.Exec(fmt.Sprintf("adb shell settings get global %s", name), true, nil)
+		value := strings.TrimSpace(output)
+		return value != "" && value != "0" && value != "null"
+	}
+	return get("wifi_on"), get("mobile_data"), get("airplane_mode_on")
+}
+
+// SetWifiEnabled switches wifi with svc, falling back to `cmd wifi` where svc is not allowed.
+func SetWifiEnabled(enabled bool) error {
+	if err := svc("wifi", enabled); err == nil {
+		return nil
+	}
+	state := map[bool]string{true: "enabled", false: "disabled"}[enabled]
+	return checkNetworkOutput(util.Exec(fmt.Sprintf("adb shell cmd wifi set-wifi-enabled %s 2>&1", state), true, nil))
+}
+
+// SetMobileDataEnabled switches mobile data.
+func SetMobileDataEnabled(enabled bool) error {
+	return svc("data", enabled)
+}
+
+// SetAirplaneMode switches airplane mode with `cmd connectivity` on Android 11+, and with the
+// setting and its broadcast on older versions. The broadcast is protected since Android 7, so the
+// fallback is only tried before that, and the setting is rolled back when the broadcast fails.
+func SetAirplaneMode(enabled bool) error {
+	state := map[bool]string{true: "enable", false: "disable"}[enabled]
+	err := checkNetworkOutput(util.Exec(fmt.Sprintf("adb shell cmd connectivity airplane-mode %s 2>&1", state), true, nil))
+	if err == nil {
+		return nil
+	}
+	if sdk := GetSDKVersion(); sdk >= 24 {
+		return fmt.Errorf("cannot switch airplane mode on sdk %d: %v", sdk, err)
+	}
+
+	previous, _ := util.Exec("adb shell settings get global airplane_mode_on", true, nil)
+	previous = strings.TrimSpace(previous)
+	value := map[bool]string{true: "1", false: "0"}[enabled]
+	if err := checkNetworkOutput(util.Exec(fmt.Sprintf("adb shell settings put global airplane_mode_on %s 2>&1", value), true, nil)); err != nil {
+		return err
+	}
+	cmd := fmt.Sprintf("adb shell am broadcast -a android.intent.action.AIRPLANE_MODE --ez state %t 2>&1", enabled)
+	if err := checkNetworkOutput(util.Exec(cmd, true, nil)); err != nil {
+		// the radios did not follow the setting, put it back
+		if previous == "0" || previous == "1" {
+			util.Exec(fmt.Sprintf("adb shell settings put global airplane_mode_on %s", previous), true, nil)
+		}
+		return err
+	}
+	return nil
+}
+
+func svc(service string, enabled bool) error {
+	state := map[bool]string{true: "enable", false: "disable"}[enabled]
+	return checkNetworkOutput(util.Exec(fmt.Sprintf("adb shell svc %s %s 2>&1", service, state), true, nil))
+}
+
+// checkNetworkOutput treats any output mentioning an error, an exception or a missing command as a failure.
+func checkNetworkOutput(output string, err error) error {
+	if err != nil {
+		return err
+	}
+	output = strings.TrimSpace(output)
+	lower := strings.ToLower(output)
+	for _, s := range []string{"error", "exception", "unknown", "not found", "usage:", "permission"} {
+		if strings.Contains(lower, s) {
+			return fmt.Errorf("%s", output)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package adb
+
+import (
+	"fmt"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+// GetPackagePaths returns every apk path of a package, base.apk first.
+func GetPackagePaths(packageName string) ([]string, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell pm path %s", packageName), false, nil)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range util.MultiLine(output) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "package:") {
+			paths = append(paths, strings.TrimPrefix(line, "package:"))
+		}
+	}
+	return paths, nil
+}
+
+// GetPackageInfo returns the parsed `dumpsys package <pkg>` entry of an installed package.
+func GetPackageInfo(packageName string) (*PackageInfo, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell dumpsys package %s", packageName), false, nil)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := ParsePackageDump(output)[packageName]
+	if !ok {
+		return nil, fmt.Errorf("package %s not found", packageName)
+	}
+	return info, nil
+}
+
+// GetPackageVersion returns the versionName and versionCode of an installed package.
+func GetPackageVersion(packageName string) (string, string, error) {
+	info, err := GetPackageInfo(packageName)
+	if err != nil {
+		return "", "", err
+	}
+	return info.VersionName, info.VersionCode, nil
+}
+
+// CheckRunAs returns an error when run-as cannot access the package, usually because it is not debuggable.
+func CheckRunAs(packageName string) error {
+	output, err := util.Exec(fmt.Sprintf("adb shell run-as %s id", packageName), true, nil)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(output, "uid=") {
+		return fmt.Errorf("run-as %s failed, the app must be a debuggable build: %s", packageName, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// RunAs runs a shell command as the app user inside its data directory.
+func RunAs(packageName, command string) (string, error) {
+	return util.Exec(fmt.Sprintf("adb shell run-as %s %s", packageName, command), true, nil)
+}
+
+// GetPackageDetail returns the parsed package entry and the components of an installed package.
+func GetPackageDetail(packageName string) (*PackageInfo, *PackageComponents, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell dumpsys package %s", packageName), false, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, ok := ParsePackageDump(output)[packageName]
+	if !ok {
+		return nil, nil, fmt.Errorf("package %s not found", packageName)
+	}
+	return info, ParsePackageComponents(output, packageName), nil
+}
@@ -0,0 +1,474 @@
+package adb
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+var packageHeaderRe = regexp.MustCompile(`^Package \[([^\]]+)\]`)
+
+// PackageInfo is an installed package as reported by `pm list packages` and `dumpsys package`.
+type PackageInfo struct {
+	Name             string   `json:"name"`
+	UID              string   `json:"uid,omitempty"`
+	APKPath          string   `json:"apkPath,omitempty"`
+	CodePath         string   `json:"codePath,omitempty"`
+	Installer        string   `json:"installer,omitempty"`
+	VersionName      string   `json:"versionName,omitempty"`
+	VersionCode      string   `json:"versionCode,omitempty"`
+	MinSDK           string   `json:"minSdk,omitempty"`
+	TargetSDK        string   `json:"targetSdk,omitempty"`
+	Flags            []string `json:"flags,omitempty"`
+	FirstInstallTime string   `json:"firstInstallTime,omitempty"`
+	LastUpdateTime   string   `json:"lastUpdateTime,omitempty"`
+	System           bool     `json:"system"`
+	Disabled         bool     `json:"disabled"`
+	Size             int64    `json:"size"`
+
+	DataDir              string               `json:"dataDir,omitempty"`
+	PrimaryCPUAbi        string               `json:"primaryCpuAbi,omitempty"`
+	PrivateFlags         []string             `json:"privateFlags,omitempty"`
+	SigningVersion       string               `json:"signingVersion,omitempty"`
+	Signatures           []string             `json:"signatures,omitempty"`
+	InstallInitiator     string               `json:"installInitiator,omitempty"`
+	InstallOriginator    string               `json:"installOriginator,omitempty"`
+	DeclaredPermissions  []DeclaredPermission `json:"declaredPermissions,omitempty"`
+	RequestedPermissions []string             `json:"requestedPermissions,omitempty"`
+	InstallPermissions   []PermissionState    `json:"installPermissions,omitempty"`
+	Users                []PackageUserState   `json:"users,omitempty"`
+}
+
+// DeclaredPermission is a permission defined by the package.
+type DeclaredPermission struct {
+	Name       string `json:"name"`
+	Protection string `json:"protection"`
+}
+
+// PermissionState is the grant state of an install or runtime permission.
+type PermissionState struct {
+	Name    string   `json:"name"`
+	Granted bool     `json:"granted"`
+	Flags   []string `json:"flags,omitempty"`
+}
+
+// PackageUserState is the per-user install state of a package.
+type PackageUserState struct {
+	ID                 string            `json:"id"`
+	Installed          bool              `json:"installed"`
+	Hidden             bool              `json:"hidden"`
+	Suspended          bool              `json:"suspended"`
+	Stopped            bool              `json:"stopped"`
+	NotLaunched        bool              `json:"notLaunched"`
+	Enabled            string            `json:"enabled"`
+	RuntimePermissions []PermissionState `json:"runtimePermissions,omitempty"`
+}
+
+// HasFlag reports whether the package flags contain flag, e.g. DEBUGGABLE.
+func (p *PackageInfo) HasFlag(flag string) bool {
+	for _, f := range p.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Debuggable reports whether android:debuggable is set.
+func (p *PackageInfo) Debuggable() bool {
+	return p.HasFlag("DEBUGGABLE")
+}
+
+// PermissionStates merges the install permissions with the runtime permissions of a user,
+// the first user when user is empty.
+func (p *PackageInfo) PermissionStates(user string) map[string]PermissionState {
+	states := make(map[string]PermissionState)
+	for _, state := range p.InstallPermissions {
+		states[state.Name] = state
+	}
+	for _, u := range p.Users {
+		if user == "" || u.ID == user {
+			for _, state := range u.RuntimePermissions {
+				states[state.Name] = state
+			}
+			break
+		}
+	}
+	return states
+}
+
+// ListPackages lists every installed package with its metadata using a fixed number of adb calls.
+func ListPackages() ([]*PackageInfo, error) {
+	output, err := util.Exec("adb shell pm list packages -f -U -i", false, nil)
+	if err != nil {
+		return nil, err
+	}
+	packages := ParsePackageList(output)
+
+	thirdParty := packageNameSet("adb shell pm list packages -3")
+	disabled := packageNameSet("adb shell pm list packages -d")
+
+	dump, err := util.Exec("adb shell dumpsys package packages", false, nil)
+	if err != nil {
+		return nil, err
+	}
+	details := ParsePackageDump(dump)
+
+	var codePaths []string
+	for _, p := range packages {
+		p.System = !thirdParty[p.Name]
+		p.Disabled = disabled[p.Name]
+		if d, ok := details[p.Name]; ok {
+			p.CodePath = d.CodePath
+			p.VersionName = d.VersionName
+			p.VersionCode = d.VersionCode
+			p.MinSDK = d.MinSDK
+			p.TargetSDK = d.TargetSDK
+			p.Flags = d.Flags
+			p.FirstInstallTime = d.FirstInstallTime
+			p.LastUpdateTime = d.LastUpdateTime
+			if p.Installer == "" {
+				p.Installer = d.Installer
+			}
+		}
+		if p.CodePath != "" {
+			codePaths = append(codePaths, p.CodePath)
+		}
+	}
+
+	// the code directory holds base.apk and every split, `pm list packages -f` only shows base.apk
+	sizes := codePathSizes(codePaths)
+	var missing []string
+	for _, p := range packages {
+		if size, ok := sizes[p.CodePath]; ok {
+			p.Size = size
+		} else if p.APKPath != "" {
+			missing = append(missing, p.APKPath)
+		}
+	}
+	apkSizes := fileSizes(missing)
+	for _, p := range packages {
+		if _, ok := sizes[p.CodePath]; !ok {
+			p.Size = apkSizes[p.APKPath]
+		}
+	}
+	return packages, nil
+}
+
+// ParsePackageList parses `pm list packages -f -U -i` output.
+func ParsePackageList(output string) []*PackageInfo {
+	var packages []*PackageInfo
+	for _, line := range util.MultiLine(output) {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "package:")
+		fields := strings.Fields(rest)
+		if !ok || len(fields) == 0 {
+			continue
+		}
+		p := &PackageInfo{}
+		// "<path>=<name> uid:<uid> installer=<installer>", the path itself may contain '='
+		for _, field := range fields[1:] {
+			if uid, ok := strings.CutPrefix(field, "uid:"); ok {
+				p.UID = uid
+			} else if installer, ok := strings.CutPrefix(field, "installer="); ok && installer != "null" {
+				p.Installer = installer
+			}
+		}
+		idx := strings.LastIndex(fields[0], "=")
+		if idx == -1 {
+			p.Name = fields[0]
+		} else {
+			p.APKPath, p.Name = fields[0][:idx], fields[0][idx+1:]
+		}
+		packages = append(packages, p)
+	}
+	return packages
+}
+
+// ParsePackageDump parses the "Packages:" section of `dumpsys package`.
+func ParsePackageDump(output string) map[string]*PackageInfo {
+	lines := nonEmptyLines(output)
+	packages := make(map[string]*PackageInfo)
+	inPackages := false
+
+	for i := 0; i < len(lines); i++ {
+		if indentOf(lines[i]) == 0 {
+			inPackages = strings.TrimSpace(lines[i]) == "Packages:"
+			continue
+		}
+		if !inPackages {
+			continue
+		}
+		m := packageHeaderRe.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if m == nil {
+			continue
+		}
+		block := indentedBlock(lines, i)
+		packages[m[1]] = parsePackageBlock(m[1], block)
+		i += len(block)
+	}
+	return packages
+}
+
+func parsePackageBlock(name string, lines []string) *PackageInfo {
+	p := &PackageInfo{Name: name}
+	if len(lines) == 0 {
+		return p
+	}
+	top := indentOf(lines[0])
+
+	for i := 0; i < len(lines); i++ {
+		if indentOf(lines[i]) != top {
+			continue
+		}
+		trimmed := strings.TrimSpace(lines[i])
+		block := indentedBlock(lines, i)
+
+		switch {
+		case trimmed == "declared permissions:":
+			for _, l := range block {
+				permission, rest, _ := strings.Cut(strings.TrimSpace(l), ":")
+				declared := DeclaredPermission{Name: permission}
+				if prot, ok := keyValues(rest)["prot"]; ok {
+					declared.Protection = strings.TrimSuffix(prot, ",")
+				}
+				p.DeclaredPermissions = append(p.DeclaredPermissions, declared)
+			}
+		case trimmed == "requested permissions:":
+			for _, l := range block {
+				permission := strings.TrimSpace(l)
+				if idx := strings.IndexAny(permission, ":,"); idx != -1 {
+					permission = permission[:idx]
+				}
+				p.RequestedPermissions = append(p.RequestedPermissions, permission)
+			}
+		case trimmed == "install permissions:":
+			p.InstallPermissions = parsePermissionStates(block)
+		case strings.HasPrefix(trimmed, "User ") && strings.Contains(trimmed, ":"):
+			p.Users = append(p.Users, parsePackageUserState(trimmed, block))
+		case strings.HasPrefix(trimmed, "flags=[") || strings.HasPrefix(trimmed, "pkgFlags=["):
+			p.Flags = parseFlagList(trimmed)
+		case strings.HasPrefix(trimmed, "privateFlags=["):
+			p.PrivateFlags = parseFlagList(trimmed)
+		case strings.HasPrefix(trimmed, "signatures="):
+			p.SigningVersion, p.Signatures = parseSignatures(trimmed)
+		case strings.HasPrefix(trimmed, "firstInstallTime="):
+			p.FirstInstallTime = strings.TrimPrefix(trimmed, "firstInstallTime=")
+		case strings.HasPrefix(trimmed, "lastUpdateTime="):
+			p.LastUpdateTime = strings.TrimPrefix(trimmed, "lastUpdateTime=")
+		default:
+			parsePackageKeyValues(p, trimmed)
+		}
+		i += len(block)
+	}
+	return p
+}
+
+func parsePackageKeyValues(p *PackageInfo, line string) {
+	for key, value := range keyValues(line) {
+		if value == "null" {
+			continue
+		}
+		switch key {
+		case "userId", "appId":
+			p.UID = value
+		case "codePath":
+			p.CodePath = value
+		case "dataDir":
+			p.DataDir = value
+		case "primaryCpuAbi":
+			p.PrimaryCPUAbi = value
+		case "versionCode":
+			p.VersionCode = value
+		case "minSdk":
+			p.MinSDK = value
+		case "targetSdk":
+			p.TargetSDK = value
+		case "versionName":
+			p.VersionName = value
+		case "installerPackageName":
+			p.Installer = value
+		case "installInitiatingPackageName":
+			p.InstallInitiator = value
+		case "installOriginatingPackageName":
+			p.InstallOriginator = value
+		}
+	}
+}
+
+func parsePackageUserState(header string, lines []string) PackageUserState {
+	id, rest, _ := strings.Cut(strings.TrimPrefix(header, "User "), ":")
+	kv := keyValues(rest)
+	state := PackageUserState{
+		ID:          id,
+		Installed:   kv["installed"] == "true",
+		Hidden:      kv["hidden"] == "true",
+		Suspended:   kv["suspended"] == "true",
+		Stopped:     kv["stopped"] == "true",
+		NotLaunched: kv["notLaunched"] == "true",
+		Enabled:     kv["enabled"],
+	}
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "runtime permissions:" {
+			block := indentedBlock(lines, i)
+			state.RuntimePermissions = parsePermissionStates(block)
+			i += len(block)
+		}
+	}
+	return state
+}
+
+// parsePermissionStates parses "android.permission.CAMERA: granted=true, flags=[ USER_SET|USER_FIXED ]" lines.
+func parsePermissionStates(lines []string) []PermissionState {
+	var states []PermissionState
+	for _, l := range lines {
+		name, rest, ok := strings.Cut(strings.TrimSpace(l), ":")
+		if !ok {
+			continue
+		}
+		state := PermissionState{Name: name, Granted: strings.Contains(rest, "granted=true")}
+		if idx := strings.Index(rest, "flags=["); idx != -1 {
+			flags := rest[idx+len("flags=["):]
+			flags, _, _ = strings.Cut(flags, "]")
+			state.Flags = strings.FieldsFunc(flags, func(r rune) bool {
+				return r == '|' || r == ' '
+			})
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+func parseFlagList(line string) []string {
+	_, flags, _ := strings.Cut(line, "=")
+	return strings.Fields(strings.Trim(flags, "[]"))
+}
+
+// parseSignatures parses "signatures=PackageSignatures{abc version:2, signatures:[1a2b3c4d], past signatures:[]}".
+func parseSignatures(line string) (string, []string) {
+	version := ""
+	if idx := strings.Index(line, "version:"); idx != -1 {
+		if fields := strings.Fields(line[idx+len("version:"):]); len(fields) > 0 {
+			version = strings.TrimRight(fields[0], ",")
+		}
+	}
+	var signatures []string
+	if idx := strings.Index(line, " signatures:["); idx != -1 {
+		list, _, _ := strings.Cut(line[idx+len(" signatures:["):], "]")
+		for _, sig := range strings.Split(list, ",") {
+			if sig = strings.TrimSpace(sig); sig != "" {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+	return version, signatures
+}
+
+func packageNameSet(cmd string) map[string]bool {
+	output, _ := util.Exec(cmd, true, nil)
+	names := make(map[string]bool)
+	for _, line := range util.MultiLine(output) {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), "package:"); ok {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// fileSizes stats device files in batches, keyed by path.
+func fileSizes(paths []string) map[string]int64 {
+	var args []string
+	for _, p := range paths {
+		args = append(args, QuoteRemote(p))
+	}
+	return statSizes(args)
+}
+
+// codePathSizes sums the base and split apks in each code directory, keyed by directory.
+func codePathSizes(dirs []string) map[string]int64 {
+	var args []string
+	for _, dir := range dirs {
+		// the glob is expanded by the device shell, only the directory is quoted there
+		args = append(args, util.ShellQuote(util.ShellQuote(dir)+"/*.apk"))
+	}
+	sizes := make(map[string]int64)
+	for file, size := range statSizes(args) {
+		sizes[path.Dir(file)] += size
+	}
+	return sizes
+}
+
+// statSizes runs `stat` on the already quoted arguments in batches.
+func statSizes(args []string) map[string]int64 {
+	const batch = 100
+	sizes := make(map[string]int64)
+	for start := 0; start < len(args); start += batch {
+		end := min(start+batch, len(args))
+		output, _ := util.Exec(fmt.Sprintf("adb shell stat -c %s %s 2>/dev/null", QuoteRemote("%s %n"), strings.Join(args[start:end], " ")), true, nil)
+		for _, line := range util.MultiLine(output) {
+			size, file, ok := strings.Cut(strings.TrimSpace(line), " ")
+			if !ok {
+				continue
+			}
+			if n, err := strconv.ParseInt(size, 10, 64); err == nil {
+				sizes[file] = n
+			}
+		}
+	}
+	return sizes
+}
+
+var resolverEntryRe = regexp.MustCompile(`^[0-9a-f]+ (\S+/\S+)(?: filter [0-9a-f]+)?$`)
+
+// PackageComponents are the components of a package found in the resolver tables.
+// Components without an intent filter are not part of `dumpsys package`.
+type PackageComponents struct {
+	Activities []string `json:"activities,omitempty"`
+	Services   []string `json:"services,omitempty"`
+	Receivers  []string `json:"receivers,omitempty"`
+	Providers  []string `json:"providers,omitempty"`
+}
+
+// ParsePackageComponents collects the components of packageName from `dumpsys package <pkg>`.
+func ParsePackageComponents(output, packageName string) *PackageComponents {
+	components := &PackageComponents{}
+	var target *[]string
+
+	add := func(list *[]string, component string) {
+		if strings.HasPrefix(component, packageName+"/") && !slices.Contains(*list, component) {
+			*list = append(*list, component)
+		}
+	}
+
+	for _, line := range nonEmptyLines(output) {
+		trimmed := strings.TrimSpace(line)
+		if indentOf(line) == 0 {
+			switch trimmed {
+			case "Activity Resolver Table:":
+				target = &components.Activities
+			case "Service Resolver Table:":
+				target = &components.Services
+			case "Receiver Resolver Table:":
+				target = &components.Receivers
+			case "Provider Resolver Table:", "Registered ContentProviders:":
+				target = &components.Providers
+			default:
+				target = nil
+			}
+			continue
+		}
+		if target == nil {
+			continue
+		}
+		if m := resolverEntryRe.FindStringSubmatch(trimmed); m != nil {
+			add(target, m[1])
+		} else if target == &components.Providers && strings.HasSuffix(trimmed, ":") && strings.Contains(trimmed, "/") {
+			add(target, strings.TrimSuffix(trimmed, ":"))
+		}
+	}
+	return components
+}
@@ -0,0 +1,209 @@
+package adb
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+// platformPermissionGroups maps the platform runtime permissions to their group.
+// Since Android 10 most of them report android.permission-group.UNDEFINED.
+var platformPermissionGroups = map[string]string{
+	"ACCESS_FINE_LOCATION":            "LOCATION",
+	"ACCESS_COARSE_LOCATION":          "LOCATION",
+	"ACCESS_BACKGROUND_LOCATION":      "LOCATION",
+	"ACCESS_MEDIA_LOCATION":           "STORAGE",
+	"CAMERA":                          "CAMERA",
+	"RECORD_AUDIO":                    "MICROPHONE",
+	"READ_CONTACTS":                   "CONTACTS",
+	"WRITE_CONTACTS":                  "CONTACTS",
+	"GET_ACCOUNTS":                    "CONTACTS",
+	"READ_CALENDAR":                   "CALENDAR",
+	"WRITE_CALENDAR":                  "CALENDAR",
+	"READ_PHONE_STATE":                "PHONE",
+	"READ_PHONE_NUMBERS":              "PHONE",
+	"CALL_PHONE":                      "PHONE",
+	"ANSWER_PHONE_CALLS":              "PHONE",
+	"ADD_VOICEMAIL":                   "PHONE",
+	"USE_SIP":                         "PHONE",
+	"ACCEPT_HANDOVER":                 "PHONE",
+	"READ_CALL_LOG":                   "CALL_LOG",
+	"WRITE_CALL_LOG":                  "CALL_LOG",
+	"PROCESS_OUTGOING_CALLS":          "CALL_LOG",
+	"SEND_SMS":                        "SMS",
+	"RECEIVE_SMS":                     "SMS",
+	"READ_SMS":                        "SMS",
+	"RECEIVE_WAP_PUSH":                "SMS",
+	"RECEIVE_MMS":                     "SMS",
+	"READ_EXTERNAL_STORAGE":           "STORAGE",
+	"WRITE_EXTERNAL_STORAGE":          "STORAGE",
+	"READ_MEDIA_IMAGES":               "READ_MEDIA_VISUAL",
+	"READ_MEDIA_VIDEO":                "READ_MEDIA_VISUAL",
+	"READ_MEDIA_VISUAL_USER_SELECTED": "READ_MEDIA_VISUAL",
+	"READ_MEDIA_AUDIO":                "READ_MEDIA_AURAL",
+	"BODY_SENSORS":                    "SENSORS",
+	"BODY_SENSORS_BACKGROUND":         "SENSORS",
+	"ACTIVITY_RECOGNITION":            "ACTIVITY_RECOGNITION",
+	"BLUETOOTH_SCAN":                  "NEARBY_DEVICES",
+	"BLUETOOTH_CONNECT":               "NEARBY_DEVICES",
+	"BLUETOOTH_ADVERTISE":             "NEARBY_DEVICES",
+	"UWB_RANGING":                     "NEARBY_DEVICES",
+	"NEARBY_WIFI_DEVICES":             "NEARBY_DEVICES",
+	"POST_NOTIFICATIONS":              "NOTIFICATIONS",
+}
+
+// PermissionDef is a permission defined on the device.
+type PermissionDef struct {
+	Name       string `json:"name"`
+	Package    string `json:"package,omitempty"`
+	Group      string `json:"group,omitempty"`
+	Protection string `json:"protection,omitempty"`
+}
+
+// IsRuntime reports whether the permission can be granted and revoked with `pm grant`.
+func (d PermissionDef) IsRuntime() bool {
+	levels := strings.Split(d.Protection, "|")
+	return slices.Contains(levels, "dangerous") || slices.Contains(levels, "development")
+}
+
+// ListPermissionDefs returns every permission of the device keyed by name.
+func ListPermissionDefs() (map[string]PermissionDef, error) {
+	output, err := util.Exec("adb shell pm list permissions -g -f", false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePermissionDefs(output), nil
+}
+
+// ParsePermissionDefs parses `pm list permissions -g -f` output.
+func ParsePermissionDefs(output string) map[string]PermissionDef {
+	defs := make(map[string]PermissionDef)
+	group := ""
+	var current *PermissionDef
+
+	flush := func() {
+		if current != nil {
+			if current.Group == "" {
+				current.Group = PermissionGroup(current.Name)
+			}
+			defs[current.Name] = *current
+			current = nil
+		}
+	}
+
+	for _, line := range nonEmptyLines(output) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "+ group:"):
+			flush()
+			group = shortGroupName(strings.TrimPrefix(trimmed, "+ group:"))
+		case trimmed == "ungrouped:":
+			flush()
+			group = ""
+		case strings.HasPrefix(trimmed, "+ permission:"):
+			flush()
+			current = &PermissionDef{Name: strings.TrimPrefix(trimmed, "+ permission:"), Group: group}
+		case current == nil:
+		case strings.HasPrefix(trimmed, "package:"):
+			current.Package = strings.TrimPrefix(trimmed, "package:")
+		case strings.HasPrefix(trimmed, "protectionLevel:"):
+			current.Protection = strings.TrimPrefix(trimmed, "protectionLevel:")
+		}
+	}
+	flush()
+	return defs
+}
+
+// PermissionGroup returns the short group name of a platform runtime permission.
+func PermissionGroup(permission string) string {
+	name, ok := strings.CutPrefix(permission, "android.permission.")
+	if !ok {
+		return ""
+	}
+	return platformPermissionGroups[name]
+}
+
+// PermissionName expands a short name such as CAMERA to android.permission.CAMERA.
+func PermissionName(name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return "android.permission." + strings.ToUpper(name)
+}
+
+func shortGroupName(group string) string {
+	group = strings.TrimPrefix(group, "android.permission-group.")
+	if group == "UNDEFINED" {
+		return ""
+	}
+	return group
+}
+
+// restorablePermissionFlags are the flags `pm set-permission-flags` accepts.
+var restorablePermissionFlags = []string{
+	"user-set",
+	"user-fixed",
+	"revoked-compat",
+	"review-required",
+	"revoke-when-requested",
+	"user-sensitive-when-granted",
+	"user-sensitive-when-denied",
+}
+
+// PermissionFlagName converts a dumpsys flag such as USER_FIXED to the pm name user-fixed.
+func PermissionFlagName(flag string) string {
+	return strings.ToLower(strings.ReplaceAll(flag, "_", "-"))
+}
+
+// RestorablePermissionFlags returns the flags that can be set with `pm set-permission-flags`.
+func RestorablePermissionFlags() []string {
+	return slices.Clone(restorablePermissionFlags)
+}
+
+// GrantPermission grants a runtime permission, the error carries the reason reported by pm.
+func GrantPermission(packageName, permission, user string) error {
+	return pmPermission("grant", packageName, permission, user)
+}
+
+// RevokePermission revokes a runtime permission, the error carries the reason reported by pm.
+func RevokePermission(packageName, permission, user string) error {
+	return pmPermission("revoke", packageName, permission, user)
+}
+
+// SetPermissionFlags sets pm permission flags such as user-fixed, requires Android 11.
+func SetPermissionFlags(packageName, permission, user string, flags []string) error {
+	return pmPermission("set-permission-flags", packageName, permission, user, flags...)
+}
+
+// ClearPermissionFlags clears pm permission flags, requires Android 11.
+func ClearPermissionFlags(packageName, permission, user string, flags []string) error {
+	return pmPermission("clear-permission-flags", packageName, permission, user, flags...)
+}
+
+func pmPermission(action, packageName, permission, user string, args ...string) error {
+	cmd := fmt.Sprintf("adb shell pm %s", action)
+	if user != "" {
+		cmd += " --user " + QuoteRemote(user)
+	}
+	cmd += fmt.Sprintf(" %s %s", packageName, QuoteRemote(permission))
+	for _, arg := range args {
+		cmd += " " + QuoteRemote(arg)
+	}
+	// pm reports failures as an exception on stderr
+	output, err := util.Exec(cmd+" 2>&1", true, nil)
+	if err != nil {
+		return err
+	}
+	for _, line := range util.MultiLine(output) {
+		line = strings.TrimSpace(line)
+		if strings.Contains(line, "Exception") || strings.HasPrefix(line, "Error") || strings.HasPrefix(line, "Unknown command") {
+			if _, reason, ok := strings.Cut(line, "Exception: "); ok {
+				return fmt.Errorf("%s", reason)
+			}
+			return fmt.Errorf("%s", line)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package adb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+var batteryStatuses = map[string]string{
+	"1": "unknown",
+	"2": "charging",
+	"3": "discharging",
+	"4": "not charging",
+	"5": "full",
+}
+
+// StandbyBuckets maps the bucket names accepted by `am set-standby-bucket` to their values.
+var StandbyBuckets = map[string]int{
+	"exempted":    5,
+	"active":      10,
+	"working_set": 20,
+	"frequent":    30,
+	"rare":        40,
+	"restricted":  45,
+	"never":       50,
+}
+
+// BatteryState is the state reported by `dumpsys battery`.
+type BatteryState struct {
+	Level       int      `json:"level"`
+	Scale       int      `json:"scale"`
+	Status      string   `json:"status"`
+	Plugged     []string `json:"plugged"`
+	Temperature float64  `json:"temperature"`
+	Simulated   bool     `json:"simulated"`
+}
+
+// GetBatteryState returns the parsed `dumpsys battery`.
+func GetBatteryState() (*BatteryState, error) {
+	output, err := util.Exec("adb shell dumpsys battery", false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBatteryState(output), nil
+}
+
+// ParseBatteryState parses `dumpsys battery`, Simulated is set once `dumpsys battery set`
+// or `unplug` stopped the updates from the real battery.
+func ParseBatteryState(output string) *BatteryState {
+	state := &BatteryState{Plugged: []string{}}
+	for _, line := range nonEmptyLines(output) {
+		line = strings.TrimSpace(line)
+		if strings.Contains(line, "UPDATES STOPPED") {
+			state.Simulated = true
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "AC powered", "USB powered", "Wireless powered", "Dock powered":
+			if value == "true" {
+				state.Plugged = append(state.Plugged, strings.TrimSuffix(key, " powered"))
+			}
+		case "level":
+			state.Level, _ = strconv.Atoi(value)
+		case "scale":
+			state.Scale, _ = strconv.Atoi(value)
+		case "status":
+			state.Status = batteryStatuses[value]
+		case "temperature":
+			t, _ := strconv.Atoi(value)
+			state.Temperature = float64(t) / 10
+		}
+	}
+	return state
+}
+
+// UnplugBattery makes the device behave as if it runs on battery.
+func UnplugBattery() error {
+	return dumpsys("battery unplug")
+}
+
+// SetBatteryLevel fakes the battery level, stopping the updates from the real battery.
+func SetBatteryLevel(level int) error {
+	return dumpsys(fmt.Sprintf("battery set level %d", level))
+}
+
+// ResetBattery restores the real battery state.
+func ResetBattery() error {
+	return dumpsys("battery reset")
+}
+
+// DeviceIdleState returns the deep or light doze state, e.g. ACTIVE, IDLE_PENDING or IDLE.
+func DeviceIdleState(mode string) (string, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell dumpsys deviceidle get %s", mode), true, nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// ForceIdle forces the device into deep or light idle.
+func ForceIdle(mode string) (string, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell dumpsys deviceidle force-idle %s 2>&1", mode), true, nil)
+	if err != nil {
+		return "", err
+	}
+	output = strings.TrimSpace(output)
+	if strings.Contains(output, "Unable") {
+		return "", fmt.Errorf("%s", output)
+	}
+	return output, nil
+}
+
+// StepIdle moves deep or light doze to its next state.
+func StepIdle(mode string) (string, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell dumpsys deviceidle step %s 2>&1", mode), true, nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// UnforceIdle leaves forced idle so the device follows its real state again.
+func UnforceIdle() error {
+	return dumpsys("deviceidle unforce")
+}
+
+// GetStandbyBucket returns the App Standby bucket name of a package.
+func GetStandbyBucket(packageName string) (string, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell am get-standby-bucket %s 2>&1", packageName), true, nil)
+	if err != nil {
+		return "", err
+	}
+	output = strings.TrimSpace(output)
+	value, err := strconv.Atoi(output)
+	if err != nil {
+		return "", fmt.Errorf("cannot get standby bucket of %s: %s", packageName, output)
+	}
+	for name, v := range StandbyBuckets {
+		if v == value {
+			return name, nil
+		}
+	}
+	return output, nil
+}
+
+// SetStandbyBucket moves a package to an App Standby bucket.
+func SetStandbyBucket(packageName, bucket string) error {
+	// exempted and never are assigned by the system only
+	if value, ok := StandbyBuckets[bucket]; !ok || value < StandbyBuckets["active"] || value > StandbyBuckets["restricted"] {
+		return fmt.Errorf("invalid standby bucket: %s (active|working_set|frequent|rare|restricted)", bucket)
+	}
+	output, err := util.Exec(fmt.Sprintf("adb shell am set-standby-bucket %s %s 2>&1", packageName, bucket), true, nil)
+	if err != nil {
+		return err
+	}
+	if output = strings.TrimSpace(output); output != "" {
+		return fmt.Errorf("cannot set standby bucket of %s: %s", packageName, output)
+	}
+	return nil
+}
+
+func dumpsys(args string) error {
+	output, err := util.Exec(fmt.Sprintf("adb shell dumpsys %s 2>&1", args), true, nil)
+	if err != nil {
+		return err
+	}
+	if output = strings.TrimSpace(output); strings.Contains(output, "Unknown") || strings.Contains(output, "Error") {
+		return fmt.Errorf("%s", output)
+	}
+	return nil
+}
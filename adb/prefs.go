@@ -0,0 +1,251 @@
+package adb
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+const sharedPrefsDir = "shared_prefs"
+
+// PrefTypes are the value types of a SharedPreferences xml file.
+var PrefTypes = []string{"string", "int", "long", "float", "boolean", "set"}
+
+// Pref is an entry of a SharedPreferences file. Set entries keep their values in Set.
+type Pref struct {
+	Key   string   `json:"key"`
+	Type  string   `json:"type"`
+	Value string   `json:"value,omitempty"`
+	Set   []string `json:"set,omitempty"`
+}
+
+// String returns the value, sets are joined with commas.
+func (p *Pref) String() string {
+	if p.Type == "set" {
+		return "[" + strings.Join(p.Set, ", ") + "]"
+	}
+	return p.Value
+}
+
+// SharedPrefs is a parsed SharedPreferences xml file keeping the entry order.
+type SharedPrefs struct {
+	Entries []*Pref `json:"entries"`
+}
+
+// Get returns the entry with the given key.
+func (p *SharedPrefs) Get(key string) (*Pref, bool) {
+	for _, e := range p.Entries {
+		if e.Key == key {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// Put replaces the entry with the same key or appends it.
+func (p *SharedPrefs) Put(pref *Pref) {
+	for i, e := range p.Entries {
+		if e.Key == pref.Key {
+			p.Entries[i] = pref
+			return
+		}
+	}
+	p.Entries = append(p.Entries, pref)
+}
+
+// Delete removes the entry with the given key and reports whether it existed.
+func (p *SharedPrefs) Delete(key string) bool {
+	for i, e := range p.Entries {
+		if e.Key == key {
+			p.Entries = append(p.Entries[:i], p.Entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// NewPref validates value against the type, set values are separated by commas.
+func NewPref(key, typ, value string) (*Pref, error) {
+	pref := &Pref{Key: key, Type: typ, Value: value}
+	var err error
+	switch typ {
+	case "string":
+	case "int":
+		_, err = strconv.ParseInt(value, 10, 32)
+	case "long":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "float":
+		_, err = strconv.ParseFloat(value, 32)
+	case "boolean":
+		var b bool
+		b, err = strconv.ParseBool(value)
+		pref.Value = strconv.FormatBool(b)
+	case "set":
+		pref.Value = ""
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				pref.Set = append(pref.Set, v)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %s, available: %s", typ, strings.Join(PrefTypes, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value %q", typ, value)
+	}
+	return pref, nil
+}
+
+// ParseSharedPrefs parses the typed xml written by SharedPreferencesImpl.
+func ParseSharedPrefs(data []byte) (*SharedPrefs, error) {
+	prefs := &SharedPrefs{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var current *Pref
+	var text strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse shared prefs: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			text.Reset()
+			if t.Name.Local == "map" {
+				continue
+			}
+			if current != nil && current.Type == "set" {
+				continue
+			}
+			current = &Pref{Type: t.Name.Local}
+			for _, attr := range t.Attr {
+				switch attr.Name.Local {
+				case "name":
+					current.Key = attr.Value
+				case "value":
+					current.Value = attr.Value
+				}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if current == nil {
+				continue
+			}
+			if current.Type == "set" && t.Name.Local == "string" {
+				current.Set = append(current.Set, text.String())
+				continue
+			}
+			if current.Type == "string" {
+				current.Value = text.String()
+			}
+			prefs.Entries = append(prefs.Entries, current)
+			current = nil
+		}
+	}
+	return prefs, nil
+}
+
+// Marshal writes the prefs in the format of SharedPreferencesImpl.
+func (p *SharedPrefs) Marshal() []byte {
+	var b bytes.Buffer
+	b.WriteString("<?xml version='1.0' encoding='utf-8' standalone='yes' ?>\n<map>\n")
+	for _, e := range p.Entries {
+		name := escapeXML(e.Key)
+		switch e.Type {
+		case "string":
+			fmt.Fprintf(&b, "    <string name=\"%s\">%s</string>\n", name, escapeXML(e.Value))
+		case "set":
+			fmt.Fprintf(&b, "    <set name=\"%s\">\n", name)
+			for _, v := range e.Set {
+				fmt.Fprintf(&b, "        <string>%s</string>\n", escapeXML(v))
+			}
+			b.WriteString("    </set>\n")
+		case "null":
+			fmt.Fprintf(&b, "    <null name=\"%s\" />\n", name)
+		default:
+			fmt.Fprintf(&b, "    <%s name=\"%s\" value=\"%s\" />\n", e.Type, name, escapeXML(e.Value))
+		}
+	}
+	b.WriteString("</map>\n")
+	return b.Bytes()
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// PrefFileName adds the .xml extension when it is missing.
+func PrefFileName(name string) string {
+	if strings.HasSuffix(name, ".xml") {
+		return name
+	}
+	return name + ".xml"
+}
+
+// ListSharedPrefs returns the SharedPreferences file names of a debuggable app.
+func ListSharedPrefs(packageName string) ([]string, error) {
+	output, err := RunAs(packageName, "ls "+sharedPrefsDir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, line := range util.MultiLine(output) {
+		if name := strings.TrimSpace(line); strings.HasSuffix(name, ".xml") {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// ReadSharedPrefs reads and parses a SharedPreferences file through run-as.
+func ReadSharedPrefs(packageName, file string) (*SharedPrefs, error) {
+	remote := path.Join(sharedPrefsDir, PrefFileName(file))
+	output, err := util.Exec(fmt.Sprintf("adb exec-out run-as %s cat %s", packageName, QuoteRemote(remote)), true, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(output, "<map") {
+		return nil, fmt.Errorf("cannot read %s: %s", remote, strings.TrimSpace(output))
+	}
+	return ParseSharedPrefs([]byte(output))
+}
+
+// WriteSharedPrefs streams the prefs into the app data directory through run-as.
+// The app must be stopped, otherwise it overwrites the file with its in-memory copy.
+func WriteSharedPrefs(packageName, file string, prefs *SharedPrefs) error {
+	tmp, err := os.CreateTemp("", "rabbit-prefs-*.xml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(prefs.Marshal())
+	tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	remote := path.Join(sharedPrefsDir, PrefFileName(file))
+	// a leftover .bak file would replace ours on the next load
+	script := fmt.Sprintf("mkdir -p %s && rm -f %s && cat > %s", sharedPrefsDir, util.ShellQuote(remote+".bak"), util.ShellQuote(remote))
+	output, err := util.Exec(fmt.Sprintf("adb exec-in run-as %s sh -c %s < %s", packageName, QuoteRemote(script), util.ShellQuote(tmp.Name())), true, nil)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(output) != "" {
+		return fmt.Errorf("write %s failed: %s", remote, strings.TrimSpace(output))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package adb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"rabbit-go/util"
+)
+
+// GetPID returns the pid of the main process of a package, 0 when it is not running.
+func GetPID(packageName string) int {
+	output, _ := util.Exec(fmt.Sprintf("adb shell pidof %s", packageName), true, nil)
+	for _, field := range strings.Fields(output) {
+		if pid, err := strconv.Atoi(field); err == nil {
+			return pid
+		}
+	}
+	return 0
+}
+
+// WaitForPID polls until the main process of a package is running.
+func WaitForPID(packageName string, timeout time.Duration) (int, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		if pid := GetPID(packageName); pid != 0 {
+			return pid, nil
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("%s did not start within %s", packageName, timeout)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// ForwardJDWP forwards a local tcp port to the JDWP transport of a process and returns the port,
+// port 0 lets adb pick a free one.
+func ForwardJDWP(port, pid int) (int, error) {
+	output, err := util.Exec(fmt.Sprintf("adb forward tcp:%d jdwp:%d 2>&1", port, pid), true, nil)
+	if err != nil {
+		return 0, err
+	}
+	if strings.Contains(output, "error") {
+		return 0, fmt.Errorf("%s", strings.TrimSpace(output))
+	}
+	if port == 0 {
+		// adb prints the allocated port
+		if port, err = strconv.Atoi(strings.TrimSpace(output)); err != nil {
+			return 0, fmt.Errorf("unexpected adb forward output: %s", strings.TrimSpace(output))
+		}
+	}
+	return port, nil
+}
@@ -0,0 +1,69 @@
+package adb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+// "* ServiceRecord{5f3e2a1 u0 com.example/.SyncService}"
+var serviceRecordRe = regexp.MustCompile(`^\* ServiceRecord\{\S+ u(\d+) ([^}\s]+)\}`)
+
+// RunningService is a service record of `dumpsys activity services`.
+type RunningService struct {
+	Component      string `json:"component"`
+	User           string `json:"user"`
+	Foreground     bool   `json:"foreground"`
+	StartRequested bool   `json:"startRequested"`
+}
+
+// GetRunningServices returns the running services of a package, of every package when it is empty.
+func GetRunningServices(packageName string) ([]*RunningService, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell dumpsys activity services %s", packageName), true, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRunningServices(output), nil
+}
+
+// ParseRunningServices parses the service records of `dumpsys activity services`.
+func ParseRunningServices(output string) []*RunningService {
+	lines := nonEmptyLines(output)
+	var services []*RunningService
+	for i, line := range lines {
+		m := serviceRecordRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		service := &RunningService{Component: m[2], User: m[1]}
+		for _, l := range indentedBlock(lines, i) {
+			kv := keyValues(l)
+			if v, ok := kv["isForeground"]; ok {
+				service.Foreground = v == "true"
+			}
+			if v, ok := kv["startRequested"]; ok {
+				service.StartRequested = v == "true"
+			}
+		}
+		services = append(services, service)
+	}
+	return services
+}
+
+// StopService stops a started service.
+func StopService(component, user string) error {
+	cmd := "adb shell am stopservice"
+	if user != "" {
+		cmd += " --user " + QuoteRemote(user)
+	}
+	output, err := util.Exec(fmt.Sprintf("%s -n %s 2>&1", cmd, QuoteRemote(component)), true, nil)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(output, "not stopped") || strings.Contains(output, "Error") {
+		return fmt.Errorf("%s", strings.TrimSpace(output))
+	}
+	return nil
+}
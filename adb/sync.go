@@ -0,0 +1,315 @@
+package adb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"rabbit-go/util"
+)
+
+const (
+	syncMaxChunk = 64 * 1024
+	modeTypeMask = 0170000
+	modeDir      = 0040000
+	modeRegular  = 0100000
+	modeSymlink  = 0120000
+)
+
+// SyncEntry is a file stat returned by the sync protocol.
+type SyncEntry struct {
+	Name  string      `json:"name"`
+	Mode  os.FileMode `json:"mode"`
+	Size  int64       `json:"size"`
+	MTime time.Time   `json:"mtime"`
+}
+
+// IsDir reports whether the entry is a directory.
+func (e *SyncEntry) IsDir() bool {
+	return e.Mode.IsDir()
+}
+
+// Progress is called while a file is transferred with the bytes done so far.
+type Progress func(done, total int64)
+
+// SyncClient is a sync session with the device, see SYNC.TXT of adb.
+type SyncClient struct {
+	conn net.Conn
+}
+
+// OpenSync connects to the adb server and starts a sync session with the device,
+// ANDROID_SERIAL selects the device like it does for adb itself.
+func OpenSync() (*SyncClient, error) {
+	conn, err := dialServer()
+	if err != nil {
+		return nil, err
+	}
+	transport := "host:transport-any"
+	if serial := os.Getenv("ANDROID_SERIAL"); serial != "" {
+		transport = "host:transport:" + serial
+	}
+	for _, request := range []string{transport, "sync:"} {
+		if err := hostRequest(conn, request); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return &SyncClient{conn: conn}, nil
+}
+
+// dialServer connects to the adb server, starting it once when it is not running.
+func dialServer() (net.Conn, error) {
+	host := os.Getenv("ANDROID_ADB_SERVER_ADDRESS")
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	port := os.Getenv("ANDROID_ADB_SERVER_PORT")
+	if port == "" {
+		port = "5037"
+	}
+	addr := net.JoinHostPort(host, port)
+
+	conn, err := net.DialTimeout("tcp", addr, 3*time.Second)
+	if err == nil {
+		return conn, nil
+	}
+	if _, startErr := util.Exec("adb start-server", true, nil); startErr != nil {
+		return nil, err
+	}
+	conn, err = net.DialTimeout("tcp", addr, 3*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the adb server at %s: %w", addr, err)
+	}
+	return conn, nil
+}
+
+// hostRequest sends a length prefixed request to the adb server and reads OKAY or FAIL.
+func hostRequest(conn net.Conn, request string) error {
+	if _, err := fmt.Fprintf(conn, "%04x%s", len(request), request); err != nil {
+		return err
+	}
+	status := make([]byte, 4)
+	if _, err := io.ReadFull(conn, status); err != nil {
+		return err
+	}
+	if string(status) == "OKAY" {
+		return nil
+	}
+	length := make([]byte, 4)
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return fmt.Errorf("%s failed", request)
+	}
+	n, _ := strconv.ParseUint(string(length), 16, 32)
+	msg := make([]byte, n)
+	_, _ = io.ReadFull(conn, msg)
+	return fmt.Errorf("adb: %s", msg)
+}
+
+// Close ends the sync session.
+func (c *SyncClient) Close() error {
+	_ = c.send("QUIT", nil)
+	return c.conn.Close()
+}
+
+func (c *SyncClient) send(id string, data []byte) error {
+	header := make([]byte, 8)
+	copy(header, id)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(data)))
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(data)
+	return err
+}
+
+func (c *SyncClient) readHeader() (string, uint32, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return "", 0, err
+	}
+	return string(header[:4]), binary.LittleEndian.Uint32(header[4:]), nil
+}
+
+// readFail reads the message of a FAIL response.
+func (c *SyncClient) readFail(length uint32) error {
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(c.conn, msg); err != nil {
+		return err
+	}
+	return errors.New(string(msg))
+}
+
+// Stat returns the entry of a remote path, nil when it does not exist.
+func (c *SyncClient) Stat(remote string) (*SyncEntry, error) {
+	if err := c.send("STAT", []byte(remote)); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 16)
+	if _, err := io.ReadFull(c.conn, buf); err != nil {
+		return nil, err
+	}
+	if string(buf[:4]) != "STAT" {
+		return nil, fmt.Errorf("unexpected sync response %q", buf[:4])
+	}
+	entry := parseSyncStat(remote, buf[4:])
+	if entry.Mode == 0 && entry.Size == 0 && entry.MTime.Unix() == 0 {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// List returns the entries of a remote directory without . and ..
+func (c *SyncClient) List(remote string) ([]*SyncEntry, error) {
+	if err := c.send("LIST", []byte(remote)); err != nil {
+		return nil, err
+	}
+	var entries []*SyncEntry
+	buf := make([]byte, 20)
+	for {
+		// FAIL is only the id and the message length, DENT and DONE are 20 byte frames
+		if _, err := io.ReadFull(c.conn, buf[:8]); err != nil {
+			return nil, err
+		}
+		id := string(buf[:4])
+		if id == "FAIL" {
+			return nil, c.readFail(binary.LittleEndian.Uint32(buf[4:8]))
+		}
+		if id != "DENT" && id != "DONE" {
+			return nil, fmt.Errorf("unexpected sync response %q", id)
+		}
+		if _, err := io.ReadFull(c.conn, buf[8:]); err != nil {
+			return nil, err
+		}
+		if id == "DONE" {
+			return entries, nil
+		}
+		name := make([]byte, binary.LittleEndian.Uint32(buf[16:20]))
+		if _, err := io.ReadFull(c.conn, name); err != nil {
+			return nil, err
+		}
+		if n := string(name); n != "." && n != ".." {
+			entries = append(entries, parseSyncStat(n, buf[4:16]))
+		}
+	}
+}
+
+func parseSyncStat(name string, b []byte) *SyncEntry {
+	return &SyncEntry{
+		Name:  name,
+		Mode:  unixFileMode(binary.LittleEndian.Uint32(b[0:4])),
+		Size:  int64(binary.LittleEndian.Uint32(b[4:8])),
+		MTime: time.Unix(int64(binary.LittleEndian.Uint32(b[8:12])), 0),
+	}
+}
+
+func unixFileMode(mode uint32) os.FileMode {
+	m := os.FileMode(mode & 0777)
+	switch mode & modeTypeMask {
+	case modeDir:
+		m |= os.ModeDir
+	case modeSymlink:
+		m |= os.ModeSymlink
+	case modeRegular:
+	default:
+		if mode&modeTypeMask != 0 {
+			m |= os.ModeIrregular
+		}
+	}
+	return m
+}
+
+// Pull copies a remote file into w.
+func (c *SyncClient) Pull(remote string, w io.Writer, total int64, progress Progress) (int64, error) {
+	if err := c.send("RECV", []byte(remote)); err != nil {
+		return 0, err
+	}
+	var done int64
+	buf := make([]byte, syncMaxChunk)
+	for {
+		id, length, err := c.readHeader()
+		if err != nil {
+			return done, err
+		}
+		switch id {
+		case "DONE":
+			return done, nil
+		case "FAIL":
+			return done, c.readFail(length)
+		case "DATA":
+		default:
+			return done, fmt.Errorf("unexpected sync response %q", id)
+		}
+		if length > syncMaxChunk {
+			return done, fmt.Errorf("sync chunk of %d bytes is too large", length)
+		}
+		if _, err := io.ReadFull(c.conn, buf[:length]); err != nil {
+			return done, err
+		}
+		if _, err := w.Write(buf[:length]); err != nil {
+			return done, err
+		}
+		done += int64(length)
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+}
+
+// Push copies r to a remote file with the given permissions and mtime.
+func (c *SyncClient) Push(r io.Reader, remote string, perm os.FileMode, mtime time.Time, total int64, progress Progress) (int64, error) {
+	if err := c.send("SEND", []byte(fmt.Sprintf("%s,%d", remote, modeRegular|uint32(perm.Perm())))); err != nil {
+		return 0, err
+	}
+	var done int64
+	buf := make([]byte, syncMaxChunk)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := c.send("DATA", buf[:n]); err != nil {
+				return done, c.pushError(err)
+			}
+			done += int64(n)
+			if progress != nil {
+				progress(done, total)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return done, err
+		}
+	}
+
+	header := make([]byte, 8)
+	copy(header, "DONE")
+	binary.LittleEndian.PutUint32(header[4:], uint32(mtime.Unix()))
+	if _, err := c.conn.Write(header); err != nil {
+		return done, c.pushError(err)
+	}
+	id, length, err := c.readHeader()
+	if err != nil {
+		return done, err
+	}
+	switch id {
+	case "OKAY":
+		return done, nil
+	case "FAIL":
+		return done, c.readFail(length)
+	}
+	return done, fmt.Errorf("unexpected sync response %q", id)
+}
+
+// pushError prefers the FAIL message adbd sends before closing the connection.
+func (c *SyncClient) pushError(err error) error {
+	_ = c.conn.SetReadDeadline(time.Now().Add(time.Second))
+	if id, length, readErr := c.readHeader(); readErr == nil && id == "FAIL" {
+		return c.readFail(length)
+	}
+	return err
+}
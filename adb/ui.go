@@ -0,0 +1,192 @@
+package adb
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+const uiDumpPath = "/sdcard/window_dump.xml"
+
+var boundsRe = regexp.MustCompile(`^\[(-?\d+),(-?\d+)\]\[(-?\d+),(-?\d+)\]$`)
+
+// Rect is the on-screen bounds of a UI node.
+type Rect struct {
+	Left   int `json:"left"`
+	Top    int `json:"top"`
+	Right  int `json:"right"`
+	Bottom int `json:"bottom"`
+}
+
+// Center returns the center point of the rect.
+func (r Rect) Center() (int, int) {
+	return (r.Left + r.Right) / 2, (r.Top + r.Bottom) / 2
+}
+
+func (r Rect) String() string {
+	return fmt.Sprintf("[%d,%d][%d,%d]", r.Left, r.Top, r.Right, r.Bottom)
+}
+
+// UINode is a view node of a uiautomator dump.
+type UINode struct {
+	Class       string    `xml:"class,attr" json:"class"`
+	ResourceID  string    `xml:"resource-id,attr" json:"resourceId,omitempty"`
+	Text        string    `xml:"text,attr" json:"text,omitempty"`
+	ContentDesc string    `xml:"content-desc,attr" json:"contentDesc,omitempty"`
+	Package     string    `xml:"package,attr" json:"package,omitempty"`
+	RawBounds   string    `xml:"bounds,attr" json:"-"`
+	Bounds      Rect      `xml:"-" json:"bounds"`
+	Clickable   bool      `xml:"clickable,attr" json:"clickable"`
+	LongClick   bool      `xml:"long-clickable,attr" json:"longClickable,omitempty"`
+	Scrollable  bool      `xml:"scrollable,attr" json:"scrollable,omitempty"`
+	Enabled     bool      `xml:"enabled,attr" json:"enabled"`
+	Focused     bool      `xml:"focused,attr" json:"focused,omitempty"`
+	Selected    bool      `xml:"selected,attr" json:"selected,omitempty"`
+	Checked     bool      `xml:"checked,attr" json:"checked,omitempty"`
+	Children    []*UINode `xml:"node" json:"children,omitempty"`
+}
+
+// UIHierarchy is the root of a uiautomator dump.
+type UIHierarchy struct {
+	Rotation int       `xml:"rotation,attr" json:"rotation"`
+	Nodes    []*UINode `xml:"node" json:"nodes"`
+}
+
+// UISelector matches nodes by id, text, content description, class or a regex.
+// All non-empty fields must match.
+type UISelector struct {
+	ID        string
+	Text      string
+	Desc      string
+	Class     string
+	Regex     *regexp.Regexp
+	Clickable bool
+}
+
+// IsEmpty reports whether the selector has no condition.
+func (s *UISelector) IsEmpty() bool {
+	return s.ID == "" && s.Text == "" && s.Desc == "" && s.Class == "" && s.Regex == nil && !s.Clickable
+}
+
+// Match reports whether the node satisfies the selector.
+// An id without a package ("login") matches "com.example:id/login".
+func (s *UISelector) Match(n *UINode) bool {
+	if s.ID != "" && n.ResourceID != s.ID && !strings.HasSuffix(n.ResourceID, ":id/"+s.ID) {
+		return false
+	}
+	if s.Text != "" && n.Text != s.Text {
+		return false
+	}
+	if s.Desc != "" && n.ContentDesc != s.Desc {
+		return false
+	}
+	if s.Class != "" && n.Class != s.Class && !strings.HasSuffix(n.Class, "."+s.Class) {
+		return false
+	}
+	if s.Regex != nil && !s.Regex.MatchString(n.Text) && !s.Regex.MatchString(n.ContentDesc) && !s.Regex.MatchString(n.ResourceID) {
+		return false
+	}
+	if s.Clickable && !n.Clickable {
+		return false
+	}
+	return true
+}
+
+// DumpUIHierarchy runs uiautomator on the device and parses the pulled xml.
+func DumpUIHierarchy() (*UIHierarchy, error) {
+	output, err := util.Exec(fmt.Sprintf("adb shell uiautomator dump %s", uiDumpPath), true, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(output, "dumped to") {
+		return nil, fmt.Errorf("uiautomator dump failed: %s", strings.TrimSpace(output))
+	}
+
+	data, err := util.Exec(fmt.Sprintf("adb exec-out cat %s", uiDumpPath), false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUIHierarchy(data)
+}
+
+// ParseUIHierarchy parses a uiautomator xml dump.
+func ParseUIHierarchy(data string) (*UIHierarchy, error) {
+	var hierarchy UIHierarchy
+	if err := xml.Unmarshal([]byte(data), &hierarchy); err != nil {
+		return nil, fmt.Errorf("parse ui hierarchy: %w", err)
+	}
+	for _, n := range hierarchy.Nodes {
+		n.walk(func(node *UINode, depth int) {
+			node.Bounds = parseBounds(node.RawBounds)
+		}, 0)
+	}
+	return &hierarchy, nil
+}
+
+// Walk visits every node depth first.
+func (h *UIHierarchy) Walk(fn func(node *UINode, depth int)) {
+	for _, n := range h.Nodes {
+		n.walk(fn, 0)
+	}
+}
+
+// Find returns all nodes matching the selector in document order.
+func (h *UIHierarchy) Find(selector *UISelector) []*UINode {
+	var nodes []*UINode
+	h.Walk(func(node *UINode, depth int) {
+		if selector.Match(node) {
+			nodes = append(nodes, node)
+		}
+	})
+	return nodes
+}
+
+func (n *UINode) walk(fn func(node *UINode, depth int), depth int) {
+	fn(n, depth)
+	for _, child := range n.Children {
+		child.walk(fn, depth+1)
+	}
+}
+
+// ShortClass strips the package from the class name.
+func (n *UINode) ShortClass() string {
+	return n.Class[strings.LastIndex(n.Class, ".")+1:]
+}
+
+// Describe returns a single line summary of the node.
+func (n *UINode) Describe() string {
+	parts := []string{n.ShortClass()}
+	if n.ResourceID != "" {
+		parts = append(parts, "id="+n.ResourceID)
+	}
+	if n.Text != "" {
+		parts = append(parts, "text="+strconv.Quote(n.Text))
+	}
+	if n.ContentDesc != "" {
+		parts = append(parts, "desc="+strconv.Quote(n.ContentDesc))
+	}
+	parts = append(parts, n.Bounds.String())
+	if n.Clickable {
+		parts = append(parts, "clickable")
+	}
+	if !n.Enabled {
+		parts = append(parts, "disabled")
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseBounds(s string) Rect {
+	m := boundsRe.FindStringSubmatch(s)
+	if m == nil {
+		return Rect{}
+	}
+	var v [4]int
+	for i := range v {
+		v[i], _ = strconv.Atoi(m[i+1])
+	}
+	return Rect{Left: v[0], Top: v[1], Right: v[2], Bottom: v[3]}
+}
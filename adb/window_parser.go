@@ -0,0 +1,171 @@
+package adb
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rabbit-go/util"
+)
+
+var (
+	windowHeaderRe = regexp.MustCompile(`^Window #(\d+) Window\{([0-9a-f]+) u(\d+) (.*)\}:$`)
+	windowRefRe    = regexp.MustCompile(`Window\{([0-9a-f]+) u\d+ ([^}]*)\}`)
+)
+
+// Window is a single window parsed from `dumpsys window windows`.
+type Window struct {
+	// ZOrder is the position in the dump, 0 being the top-most window.
+	ZOrder  int
+	Hash    string
+	User    string
+	Title   string
+	Package string
+	Type    string
+	Visible bool
+	Focused bool
+}
+
+// WindowState is the parsed window stack of the default display.
+type WindowState struct {
+	Windows       []*Window
+	Focused       *Window
+	FocusedApp    string
+	ImeWindow     *Window
+	KeyboardShown bool
+}
+
+// IsActivity reports whether the window is the main window of an activity.
+func (w *Window) IsActivity() bool {
+	return w.Type == "BASE_APPLICATION"
+}
+
+// GetWindowState returns the parsed window stack together with the keyboard state.
+func GetWindowState() (*WindowState, error) {
+	output, err := util.Exec("adb shell dumpsys window windows", false, nil)
+	if err != nil {
+		return nil, err
+	}
+	state := ParseWindowDump(output)
+
+	ime, _ := util.Exec("adb shell dumpsys input_method", true, nil)
+	if shown, ok := ParseInputShown(ime); ok {
+		state.KeyboardShown = shown
+	} else if state.ImeWindow != nil {
+		state.KeyboardShown = state.ImeWindow.Visible
+	}
+	return state, nil
+}
+
+// ParseWindowDump parses `dumpsys window windows` output.
+func ParseWindowDump(output string) *WindowState {
+	lines := nonEmptyLines(output)
+	state := &WindowState{}
+	focusedHash := ""
+	imeHash := ""
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+
+		if m := windowHeaderRe.FindStringSubmatch(trimmed); m != nil {
+			block := indentedBlock(lines, i)
+			z, _ := strconv.Atoi(m[1])
+			state.Windows = append(state.Windows, parseWindow(z, m[2], m[3], m[4], block))
+			i += len(block)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "mCurrentFocus="):
+			if m := windowRefRe.FindStringSubmatch(trimmed); m != nil {
+				focusedHash = m[1]
+			}
+		case strings.HasPrefix(trimmed, "mFocusedApp="):
+			state.FocusedApp = focusedAppComponent(strings.TrimPrefix(trimmed, "mFocusedApp="))
+		case strings.HasPrefix(trimmed, "mInputMethodWindow="):
+			if m := windowRefRe.FindStringSubmatch(trimmed); m != nil {
+				imeHash = m[1]
+			}
+		}
+	}
+
+	for _, w := range state.Windows {
+		if w.Hash == focusedHash {
+			w.Focused = true
+			state.Focused = w
+		}
+		if w.Hash == imeHash || (imeHash == "" && w.Type == "INPUT_METHOD") {
+			state.ImeWindow = w
+		}
+	}
+	return state
+}
+
+func parseWindow(z int, hash, user, title string, lines []string) *Window {
+	w := &Window{ZOrder: z, Hash: hash, User: user, Title: title}
+	hasSurface := false
+	viewVisible := false
+	visibleKnown := false
+
+	for _, l := range lines {
+		for key, value := range keyValues(l) {
+			switch key {
+			case "package":
+				w.Package = value
+			case "ty":
+				w.Type = strings.TrimRight(value, "},")
+			case "isVisible", "isOnScreen":
+				visibleKnown = true
+				w.Visible = w.Visible || value == "true"
+			case "mHasSurface":
+				hasSurface = value == "true"
+			case "mViewVisibility":
+				viewVisible = value == "0x0"
+			}
+		}
+	}
+	if !visibleKnown {
+		w.Visible = hasSurface && viewVisible
+	}
+	if w.Package == "" {
+		if idx := strings.Index(title, "/"); idx != -1 {
+			w.Package = title[:idx]
+		}
+	}
+	return w
+}
+
+// ParseInputShown reads mInputShown from `dumpsys input_method`.
+func ParseInputShown(output string) (bool, bool) {
+	for _, l := range util.MultiLine(output) {
+		if v, ok := keyValues(l)["mInputShown"]; ok {
+			return v == "true", true
+		}
+	}
+	return false, false
+}
+
+// focusedAppComponent extracts the component from an ActivityRecord{... u0 pkg/.Activity t12} value.
+func focusedAppComponent(value string) string {
+	for _, field := range strings.Fields(value) {
+		if strings.Contains(field, "/") {
+			return strings.TrimSuffix(field, "}")
+		}
+	}
+	return ""
+}
+
+// VisibleStack returns the visible windows from the top down to the first activity window.
+func (s *WindowState) VisibleStack() []*Window {
+	var stack []*Window
+	for _, w := range s.Windows {
+		if !w.Visible {
+			continue
+		}
+		stack = append(stack, w)
+		if w.IsActivity() {
+			break
+		}
+	}
+	return stack
+}
@@ -30,7 +30,7 @@ func init() {
 	// Log options
 	rootCmd.Flags().BoolVarP(&logConfig.LogCurrentActivity, "current", "c", false, "print current activity name")
 	rootCmd.Flags().BoolVarP(&logConfig.LogAllActivity, "all", "a", false, "print all activities name")
-	rootCmd.Flags().BoolVarP(&logConfig.LogAllFragment, "fragment", "f", false, "print specific package fragments")
+	rootCmd.Flags().BoolVarP(&logConfig.LogAllFragment, "fragment", "f", false, "print fragment tree of current package")
 	rootCmd.Flags().StringVarP(&logConfig.LogSpecificPackageActivity, "print", "p", "", "print specific package activities")
 
 	// App options
@@ -41,7 +41,9 @@ func init() {
 	rootCmd.Flags().StringVar(&appConfig.StartAppPackageName, "start", "", "start app")
 	rootCmd.Flags().StringVar(&appConfig.RestartPackageName, "restart", "", "restart app")
 	rootCmd.Flags().StringVar(&appConfig.StartAppDetailPackageName, "detail", "", "start app detail page")
-	rootCmd.Flags().StringVar(&appConfig.ExportPackageName, "export", "", "export app to desktop")
+	rootCmd.Flags().StringVar(&appConfig.ExportPackageName, "export", "", "export app base and split apks")
+	rootCmd.Flags().StringVar(&appConfig.ExportDir, "export-dir", "", "destination directory of --export")
+	rootCmd.Flags().BoolVar(&appConfig.ExportAsApks, "export-apks", false, "export as a single .apks archive")
 
 	// Action config
 	rootCmd.Flags().StringVar(&actionConfig, "action", "", "android adb start system activity (locale|developer|application|notification|bluetooth|input|display)")
@@ -130,7 +132,7 @@ func executeAppCommands(config config.AppConfig) {
 		strategy.NewStartActivityStrategy(config.StartAppPackageName),
 		strategy.NewRestartAppStrategy(config.RestartPackageName),
 		strategy.NewStartAppDetailStrategy(config.StartAppDetailPackageName),
-		strategy.NewExportAppStrategy(config.ExportPackageName),
+		strategy.NewExportAppStrategy(config.ExportPackageName, config.ExportDir, config.ExportAsApks),
 	}
 
 	for _, s := range strategies {
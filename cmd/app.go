@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"rabbit-go/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	appBackupDir string
+	appInfo      strategy.AppInfoStrategy
+	appMem       strategy.AppMemStrategy
+)
+
+var appCmd = &cobra.Command{
+	Use:   "app",
+	Short: "app data and state commands",
+}
+
+var appInfoCmd = &cobra.Command{
+	Use:   "info <packageName>",
+	Short: "show version, flags, signing, install source, user state, permissions and components of an app",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		appInfo.PackageName = args[0]
+		runStrategy(&appInfo)
+	},
+}
+
+var appMemCmd = &cobra.Command{
+	Use:   "mem <packageName>",
+	Short: "show PSS, RSS and private dirty memory by category and leak hinting object counts",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		appMem.PackageName = args[0]
+		runStrategy(&appMem)
+	},
+}
+
+var appBackupCmd = &cobra.Command{
+	Use:   "backup <packageName>",
+	Short: "back up databases, shared_prefs, files and no_backup of a debuggable app",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		s := &strategy.AppBackupStrategy{PackageName: args[0], Dir: appBackupDir}
+		runStrategy(s)
+	},
+}
+
+var appRestoreCmd = &cobra.Command{
+	Use:   "restore <packageName> <archive>",
+	Short: "restore a backup created by app backup",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		s := &strategy.AppRestoreStrategy{PackageName: args[0], Archive: args[1]}
+		runStrategy(s)
+	},
+}
+
+func init() {
+	appBackupCmd.Flags().StringVarP(&appBackupDir, "output", "o", "", "directory of the backup archive")
+
+	appInfoCmd.Flags().BoolVar(&appInfo.JSON, "json", false, "print as json")
+	appInfoCmd.Flags().BoolVar(&appInfo.Certs, "certs", false, "pull base.apk and print the SHA-256 digests of its signing certificates")
+
+	appMemCmd.Flags().BoolVar(&appMem.JSON, "json", false, "print as json")
+	appMemCmd.Flags().DurationVarP(&appMem.Watch, "watch", "w", 0, "sample at this interval and write a csv, e.g. 5s")
+	appMemCmd.Flags().DurationVar(&appMem.Duration, "duration", 0, "stop watching after this duration, defaults to Ctrl + C")
+	appMemCmd.Flags().StringVar(&appMem.CSVPath, "csv", "", "csv file of --watch")
+
+	appCmd.AddCommand(appInfoCmd, appMemCmd, appBackupCmd, appRestoreCmd)
+	rootCmd.AddCommand(appCmd)
+}
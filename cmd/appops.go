@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"rabbit-go/strategy"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	appOpsGet strategy.AppOpsGetStrategy
+	appOpsSet strategy.AppOpsSetStrategy
+)
+
+var appOpsCmd = &cobra.Command{
+	Use:   "appops",
+	Short: "inspect and change app ops such as overlay, background running and clipboard access",
+}
+
+var appOpsGetCmd = &cobra.Command{
+	Use:   "get <packageName> [op]",
+	Short: "list app ops with mode and last access time",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		appOpsGet.PackageName = args[0]
+		if len(args) > 1 {
+			appOpsGet.Op = args[1]
+		}
+		runStrategy(&appOpsGet)
+	},
+}
+
+var appOpsSetCmd = &cobra.Command{
+	Use:   "set <packageName> <op> <mode> | set <packageName> <preset>",
+	Short: "set an op to allow|ignore|deny|default|foreground, or apply a preset",
+	Long: "Set an op to allow, ignore, deny, default or foreground, or apply a preset.\n\nPresets: " +
+		strings.Join(strategy.AppOpPresetNames(), ", "),
+	Example: `  rabbit-go appops set com.example SYSTEM_ALERT_WINDOW allow
+  rabbit-go appops set com.example restrict-background`,
+	Args: cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		appOpsSet.PackageName, appOpsSet.Op = args[0], args[1]
+		if len(args) > 2 {
+			appOpsSet.Mode = args[2]
+		}
+		runStrategy(&appOpsSet)
+	},
+}
+
+var appOpsResetCmd = &cobra.Command{
+	Use:   "reset <packageName> [op]",
+	Short: "reset one op or all ops of a package to the default mode",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		s := &strategy.AppOpsResetStrategy{PackageName: args[0]}
+		if len(args) > 1 {
+			s.Op = args[1]
+		}
+		runStrategy(s)
+	},
+}
+
+func init() {
+	appOpsGetCmd.Flags().BoolVar(&appOpsGet.JSON, "json", false, "print as json")
+	appOpsSetCmd.Flags().BoolVar(&appOpsSet.UID, "uid", false, "set the uid mode instead of the package mode")
+
+	appOpsCmd.AddCommand(appOpsGetCmd, appOpsSetCmd, appOpsResetCmd)
+	rootCmd.AddCommand(appOpsCmd)
+}
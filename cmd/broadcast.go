@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"rabbit-go/config"
+
+	"github.com/spf13/cobra"
+)
+
+var broadcastConfig config.IntentConfig
+
+var broadcastCmd = &cobra.Command{
+	Use:   "broadcast [action]",
+	Short: "send a broadcast with extras",
+	Example: `  rabbit-go broadcast com.example.REFRESH -p com.example --es source=cli --ei count=3
+  rabbit-go broadcast -n com.example/.SyncReceiver --extras extras.json`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 {
+			broadcastConfig.Action = args[0]
+		}
+		runNewStrategy(newIntentStrategy("broadcast", broadcastConfig))
+	},
+}
+
+func init() {
+	addIntentFlags(broadcastCmd, &broadcastConfig)
+	rootCmd.AddCommand(broadcastCmd)
+}
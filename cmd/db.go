@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"rabbit-go/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dbList  strategy.DBListStrategy
+	dbPull  strategy.DBPullStrategy
+	dbQuery strategy.DBQueryStrategy
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "list, pull and query SQLite databases of a debuggable app",
+}
+
+var dbListCmd = &cobra.Command{
+	Use:   "list <packageName>",
+	Short: "list the databases directory",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dbList.PackageName = args[0]
+		runStrategy(&dbList)
+	},
+}
+
+var dbPullCmd = &cobra.Command{
+	Use:   "pull <packageName> <name>",
+	Short: "copy a database with its -wal and -shm files",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		dbPull.PackageName, dbPull.Name = args[0], args[1]
+		runStrategy(&dbPull)
+	},
+}
+
+var dbQueryCmd = &cobra.Command{
+	Use:   "query <packageName> <name> <sql>",
+	Short: "run a SELECT on a temporary copy of a database",
+	Long: `Run a SELECT on a temporary copy of a database with the built-in reader.
+Supported: SELECT [DISTINCT] *|columns|COUNT(*) FROM table [WHERE ...] [ORDER BY ...] [LIMIT n [OFFSET m]],
+WHERE supports =, !=, <, <=, >, >=, AND, OR, NOT, LIKE, IN, BETWEEN and IS [NOT] NULL.
+Use sqlite_master to list the tables.`,
+	Example: `  rabbit-go db query com.example app.db "SELECT name FROM sqlite_master"
+  rabbit-go db query com.example app.db "SELECT * FROM user WHERE age > 18 ORDER BY id DESC LIMIT 10"`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		dbQuery.PackageName, dbQuery.Name, dbQuery.SQL = args[0], args[1], args[2]
+		runStrategy(&dbQuery)
+	},
+}
+
+func init() {
+	dbListCmd.Flags().BoolVar(&dbList.JSON, "json", false, "print as json")
+	dbPullCmd.Flags().StringVarP(&dbPull.Dir, "output", "o", "", "destination directory")
+	dbPullCmd.Flags().BoolVar(&dbPull.Stop, "stop", false, "force-stop the app first so no transaction is written while copying")
+	dbQueryCmd.Flags().BoolVar(&dbQuery.JSON, "json", false, "print rows as json")
+	dbQueryCmd.Flags().BoolVar(&dbQuery.Stop, "stop", false, "force-stop the app first so no transaction is written while copying")
+
+	dbCmd.AddCommand(dbListCmd, dbPullCmd, dbQueryCmd)
+	rootCmd.AddCommand(dbCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"rabbit-go/strategy"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	debugSet   strategy.DebugSetStrategy
+	debugStart strategy.DebugStartStrategy
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "make an app wait for the debugger to catch startup code",
+}
+
+var debugSetCmd = &cobra.Command{
+	Use:   "set <packageName>",
+	Short: "set the debug app with am set-debug-app -w",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		debugSet.PackageName = args[0]
+		runStrategy(&debugSet)
+	},
+}
+
+var debugClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "clear the debug app",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runStrategy(&strategy.DebugClearStrategy{})
+	},
+}
+
+var debugStartCmd = &cobra.Command{
+	Use:   "start <packageName>",
+	Short: "start the launcher activity with am start -D and forward the JDWP port",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		debugStart.PackageName = args[0]
+		runStrategy(&debugStart)
+	},
+}
+
+func init() {
+	debugSetCmd.Flags().BoolVar(&debugSet.NoWait, "no-wait", false, "do not wait for the debugger")
+	debugSetCmd.Flags().BoolVar(&debugSet.Persistent, "persistent", true, "keep the debug app until cleared, not only for the next launch")
+	debugStartCmd.Flags().StringVar(&debugStart.Component, "activity", "", "component to start, defaults to the launcher activity")
+	debugStartCmd.Flags().IntVar(&debugStart.Port, "port", 0, "local port forwarded to the JDWP transport, defaults to a free port")
+	debugStartCmd.Flags().DurationVar(&debugStart.Timeout, "timeout", 10*time.Second, "time to wait for the process")
+
+	debugCmd.AddCommand(debugSetCmd, debugClearCmd, debugStartCmd)
+	rootCmd.AddCommand(debugCmd)
+}
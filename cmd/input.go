@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/strategy"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	inputIndex         int
+	inputLong          bool
+	inputPressDuration time.Duration
+	inputSwipeDuration time.Duration
+	inputDragDuration  time.Duration
+)
+
+var inputCmd = &cobra.Command{
+	Use:   "input",
+	Short: "send taps, swipes, text and key events",
+}
+
+var inputTapCmd = &cobra.Command{
+	Use:   "tap [x y]",
+	Short: "tap coordinates or the element matched by a selector",
+	Example: `  rabbit-go input tap 540 1200
+  rabbit-go input tap --id login
+  rabbit-go input tap --text "Sign in"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runNewStrategy(newInputTapStrategy(args, false, 0))
+	},
+}
+
+var inputLongPressCmd = &cobra.Command{
+	Use:   "long-press [x y]",
+	Short: "long-press coordinates or the element matched by a selector",
+	Run: func(cmd *cobra.Command, args []string) {
+		runNewStrategy(newInputTapStrategy(args, true, inputPressDuration))
+	},
+}
+
+var inputSwipeCmd = &cobra.Command{
+	Use:   "swipe <x1> <y1> <x2> <y2>",
+	Short: "swipe between two points",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		runNewStrategy(newInputSwipeStrategy(args, false, inputSwipeDuration))
+	},
+}
+
+var inputDragCmd = &cobra.Command{
+	Use:   "drag <x1> <y1> <x2> <y2>",
+	Short: "drag and drop between two points",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		runNewStrategy(newInputSwipeStrategy(args, true, inputDragDuration))
+	},
+}
+
+var inputTextCmd = &cobra.Command{
+	Use:   "text <text>",
+	Short: "type text into the focused field, non ascii text needs ADBKeyBoard",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runNewStrategy(&strategy.InputTextStrategy{Text: args[0]}, nil)
+	},
+}
+
+var inputKeyCmd = &cobra.Command{
+	Use:   "key <name>...",
+	Short: "send key events (" + strings.Join(adb.KeyNames(), "|") + "), KEYCODE_* names or numbers",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runNewStrategy(&strategy.InputKeyStrategy{Keys: args, Long: inputLong}, nil)
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{inputTapCmd, inputLongPressCmd} {
+		addUISelectorFlags(cmd)
+		cmd.Flags().IntVar(&inputIndex, "index", 0, "index of the matched element")
+	}
+	inputLongPressCmd.Flags().DurationVar(&inputPressDuration, "duration", 800*time.Millisecond, "press duration")
+	inputSwipeCmd.Flags().DurationVar(&inputSwipeDuration, "duration", 300*time.Millisecond, "swipe duration")
+	inputDragCmd.Flags().DurationVar(&inputDragDuration, "duration", time.Second, "drag duration")
+	inputKeyCmd.Flags().BoolVar(&inputLong, "long", false, "long press the key")
+
+	inputCmd.AddCommand(inputTapCmd, inputLongPressCmd, inputSwipeCmd, inputDragCmd, inputTextCmd, inputKeyCmd)
+	rootCmd.AddCommand(inputCmd)
+}
+
+func newInputTapStrategy(args []string, long bool, duration time.Duration) (runnable, error) {
+	selector, err := buildUISelector()
+	if err != nil {
+		return nil, err
+	}
+	s := &strategy.InputTapStrategy{Selector: selector, Index: inputIndex, Long: long, Duration: duration}
+	if selector.IsEmpty() {
+		points, err := parseInts(args, 2)
+		if err != nil {
+			return nil, err
+		}
+		s.X, s.Y = points[0], points[1]
+	}
+	return s, nil
+}
+
+func newInputSwipeStrategy(args []string, drag bool, duration time.Duration) (runnable, error) {
+	points, err := parseInts(args, 4)
+	if err != nil {
+		return nil, err
+	}
+	return &strategy.InputSwipeStrategy{
+		X1: points[0], Y1: points[1], X2: points[2], Y2: points[3],
+		Duration: duration,
+		Drag:     drag,
+	}, nil
+}
+
+func parseInts(args []string, n int) ([]int, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("expected %d coordinates, got %d", n, len(args))
+	}
+	values := make([]int, n)
+	for i, arg := range args {
+		v, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coordinate: %s", arg)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
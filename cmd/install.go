@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"rabbit-go/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+var install strategy.InstallStrategy
+
+var installCmd = &cobra.Command{
+	Use:   "install <apk>... | <bundle.apks|.xapk|.zip>",
+	Short: "install an apk, split apks or an .apks/.xapk bundle",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		install.Files = args
+		runStrategy(&install)
+	},
+}
+
+func init() {
+	installCmd.Flags().BoolVarP(&install.Replace, "replace", "r", false, "replace the existing app")
+	installCmd.Flags().BoolVarP(&install.Downgrade, "downgrade", "d", false, "allow version code downgrade")
+	installCmd.Flags().BoolVarP(&install.GrantAll, "grant", "g", false, "grant all runtime permissions")
+	installCmd.Flags().BoolVarP(&install.TestOnly, "test-only", "t", false, "allow test-only apks")
+	installCmd.Flags().StringVar(&install.User, "user", "", "install for the given user id")
+	rootCmd.AddCommand(installCmd)
+}
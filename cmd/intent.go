@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"rabbit-go/adb"
+	"rabbit-go/config"
+	"rabbit-go/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+var intentConfig config.IntentConfig
+
+var intentCmd = &cobra.Command{
+	Use:   "intent",
+	Short: "start an activity or service, or send a broadcast with a custom intent",
+	Example: `  rabbit-go intent -a android.intent.action.VIEW -d "https://example.com/item/1" -p com.example
+  rabbit-go intent -n com.example/.DetailActivity --es id=42 --ez debug=true -f NEW_TASK -f CLEAR_TOP
+  rabbit-go intent --target broadcast -a com.example.REFRESH --extras extras.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runNewStrategy(newIntentStrategy(intentConfig.Target, intentConfig))
+	},
+}
+
+func init() {
+	intentCmd.Flags().StringVar(&intentConfig.Target, "target", "activity", "intent target (activity|service|broadcast)")
+	addIntentFlags(intentCmd, &intentConfig)
+	rootCmd.AddCommand(intentCmd)
+}
+
+// addIntentFlags adds the flags describing an intent, shared by intent, broadcast and service start
+func addIntentFlags(cmd *cobra.Command, c *config.IntentConfig) {
+	flags := cmd.Flags()
+	flags.StringVarP(&c.Component, "component", "n", "", "explicit component, e.g. com.example/.MainActivity")
+	flags.StringVarP(&c.Action, "action", "a", "", "intent action")
+	flags.StringVarP(&c.Data, "data", "d", "", "data uri")
+	flags.StringVarP(&c.MimeType, "type", "t", "", "mime type")
+	flags.StringVarP(&c.Package, "package", "p", "", "limit resolution to a package")
+	flags.StringArrayVarP(&c.Categories, "category", "c", nil, "intent category, repeatable")
+	flags.StringArrayVarP(&c.Flags, "flag", "f", nil, "launch flag name (NEW_TASK, CLEAR_TOP...) or hex value, repeatable")
+	flags.StringArrayVar(&c.StringExtras, "es", nil, "string extra key=value, repeatable")
+	flags.StringArrayVar(&c.IntExtras, "ei", nil, "int extra key=value, repeatable")
+	flags.StringArrayVar(&c.BoolExtras, "ez", nil, "bool extra key=value, repeatable")
+	flags.StringArrayVar(&c.LongExtras, "el", nil, "long extra key=value, repeatable")
+	flags.StringArrayVar(&c.ArrayExtras, "esa", nil, "string array extra key=a,b,c, repeatable")
+	flags.StringVar(&c.ExtrasFile, "extras", "", "load extras from a json file")
+	flags.StringVar(&c.User, "user", "", "target user id")
+	flags.BoolVar(&c.DryRun, "dry-run", false, "only resolve the intent and print the am command")
+}
+
+func buildIntent(c config.IntentConfig) (*adb.Intent, error) {
+	intent := &adb.Intent{
+		Component:  c.Component,
+		Action:     c.Action,
+		Data:       c.Data,
+		MimeType:   c.MimeType,
+		Package:    c.Package,
+		Categories: c.Categories,
+		Flags:      c.Flags,
+	}
+
+	if c.ExtrasFile != "" {
+		extras, err := adb.LoadExtras(c.ExtrasFile)
+		if err != nil {
+			return nil, err
+		}
+		intent.Extras = append(intent.Extras, extras...)
+	}
+
+	typed := []struct {
+		extraType string
+		pairs     []string
+	}{
+		{"string", c.StringExtras},
+		{"int", c.IntExtras},
+		{"bool", c.BoolExtras},
+		{"long", c.LongExtras},
+		{"string-array", c.ArrayExtras},
+	}
+	for _, t := range typed {
+		for _, pair := range t.pairs {
+			extra, err := adb.ParseExtra(t.extraType, pair)
+			if err != nil {
+				return nil, err
+			}
+			intent.Extras = append(intent.Extras, extra)
+		}
+	}
+	return intent, nil
+}
+
+func newIntentStrategy(target string, c config.IntentConfig) (*strategy.IntentStrategy, error) {
+	intent, err := buildIntent(c)
+	if err != nil {
+		return nil, err
+	}
+	return &strategy.IntentStrategy{Target: target, Intent: intent, User: c.User, DryRun: c.DryRun}, nil
+}
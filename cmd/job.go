@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"rabbit-go/strategy"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	jobList strategy.JobListStrategy
+	jobRun  strategy.JobRunStrategy
+)
+
+var jobCmd = &cobra.Command{
+	Use:   "job",
+	Short: "list and force-run JobScheduler jobs, WorkManager work included",
+}
+
+var jobListCmd = &cobra.Command{
+	Use:   "list [packageName]",
+	Short: "list jobs with their constraints, last run and next run, of every package when none is given",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			jobList.PackageName = args[0]
+		}
+		runStrategy(&jobList)
+	},
+}
+
+var jobRunCmd = &cobra.Command{
+	Use:   "run <packageName> [id]...",
+	Short: "run jobs now, ignoring their constraints unless --force=false",
+	Example: `  rabbit-go job run com.example 5
+  rabbit-go job run com.example --all`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		jobRun.PackageName = args[0]
+		var err error
+		for _, arg := range args[1:] {
+			id, e := strconv.Atoi(arg)
+			if e != nil {
+				err = fmt.Errorf("invalid job id: %s", arg)
+				break
+			}
+			jobRun.IDs = append(jobRun.IDs, id)
+		}
+		runNewStrategy(&jobRun, err)
+	},
+}
+
+func init() {
+	jobListCmd.Flags().BoolVar(&jobList.JSON, "json", false, "print as json")
+	jobRunCmd.Flags().BoolVar(&jobRun.All, "all", false, "run every job of the package")
+	jobRunCmd.Flags().BoolVar(&jobRun.Force, "force", true, "run even when the constraints are not satisfied")
+	jobRunCmd.Flags().StringVar(&jobRun.User, "user", "", "target user id")
+
+	jobCmd.AddCommand(jobListCmd, jobRunCmd)
+	rootCmd.AddCommand(jobCmd)
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"rabbit-go/strategy"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var launchBench strategy.LaunchBenchStrategy
+
+var launchBenchCmd = &cobra.Command{
+	Use:   "launch-bench <packageName>",
+	Short: "measure cold, warm or hot launch time with am start -W",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		launchBench.PackageName = args[0]
+		runStrategy(&launchBench)
+	},
+}
+
+func init() {
+	launchBenchCmd.Flags().IntVarP(&launchBench.Runs, "runs", "n", 10, "number of measured launches")
+	launchBenchCmd.Flags().StringVarP(&launchBench.Mode, "mode", "m", strategy.LaunchModeCold, "launch mode (cold|warm|hot)")
+	launchBenchCmd.Flags().StringVar(&launchBench.Component, "activity", "", "component to launch, defaults to the launcher activity")
+	launchBenchCmd.Flags().BoolVar(&launchBench.DropCaches, "drop-caches", false, "drop page caches before each cold launch (root only)")
+	launchBenchCmd.Flags().DurationVar(&launchBench.Delay, "delay", 2*time.Second, "wait time after each launch")
+	launchBenchCmd.Flags().StringVar(&launchBench.CSVPath, "csv", "", "export runs to a csv file")
+	launchBenchCmd.Flags().StringVar(&launchBench.JSONPath, "json", "", "export runs and summary to a json file")
+	rootCmd.AddCommand(launchBenchCmd)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"rabbit-go/strategy"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var netStrategy strategy.NetStrategy
+
+var netCmd = &cobra.Command{
+	Use:   "net",
+	Short: "toggle wifi, mobile data and airplane mode, and show the network state",
+}
+
+var netStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show the active network, validation, ip addresses and dns servers",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		netStrategy.Action = "status"
+		runStrategy(&netStrategy)
+	},
+}
+
+func newNetToggleCmd(action, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:       action + " <on|off>",
+		Short:     short,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"on", "off"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			switch args[0] {
+			case "on", "off":
+				netStrategy.Enabled = args[0] == "on"
+			default:
+				err = fmt.Errorf("expected on or off, got %s", args[0])
+			}
+			netStrategy.Action = action
+			runNewStrategy(&netStrategy, err)
+		},
+	}
+}
+
+func init() {
+	netCmd.PersistentFlags().BoolVar(&netStrategy.JSON, "json", false, "print the network state as json")
+	netCmd.PersistentFlags().DurationVar(&netStrategy.Wait, "wait", 2*time.Second, "wait time before showing the state after a change")
+
+	netCmd.AddCommand(
+		newNetToggleCmd("wifi", "turn wifi on or off"),
+		newNetToggleCmd("data", "turn mobile data on or off"),
+		newNetToggleCmd("airplane", "turn airplane mode on or off"),
+		netStatusCmd,
+	)
+	rootCmd.AddCommand(netCmd)
+}
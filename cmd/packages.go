@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"rabbit-go/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+var packages strategy.PackagesStrategy
+
+var packagesCmd = &cobra.Command{
+	Use:   "packages",
+	Short: "list installed packages with version, sdk, install time and size",
+	Run: func(cmd *cobra.Command, args []string) {
+		runStrategy(&packages)
+	},
+}
+
+func init() {
+	packagesCmd.Flags().BoolVarP(&packages.ThirdParty, "third-party", "3", false, "only third-party packages")
+	packagesCmd.Flags().BoolVarP(&packages.System, "system", "s", false, "only system packages")
+	packagesCmd.Flags().BoolVarP(&packages.Disabled, "disabled", "d", false, "only disabled packages")
+	packagesCmd.Flags().BoolVar(&packages.Debuggable, "debuggable", false, "only debuggable packages")
+	packagesCmd.Flags().StringVar(&packages.Installer, "installer", "", "only packages installed by this installer, e.g. com.android.vending")
+	packagesCmd.Flags().StringVarP(&packages.Pattern, "regex", "e", "", "only package names matching the regex")
+	packagesCmd.Flags().BoolVar(&packages.JSON, "json", false, "print as json")
+	rootCmd.AddCommand(packagesCmd)
+}
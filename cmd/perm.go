@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"os"
+	"rabbit-go/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	permList   strategy.PermListStrategy
+	permChange strategy.PermChangeStrategy
+	permDir    string
+	permUser   string
+)
+
+var permCmd = &cobra.Command{
+	Use:   "perm",
+	Short: "list, grant and revoke runtime permissions",
+}
+
+var permListCmd = &cobra.Command{
+	Use:   "list <packageName>",
+	Short: "list requested permissions with group, protection level, grant state and flags",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		permList.PackageName = args[0]
+		runStrategy(&permList)
+	},
+}
+
+var permGrantCmd = &cobra.Command{
+	Use:   "grant <packageName> [permission...]",
+	Short: "grant permissions, groups or every dangerous permission",
+	Example: `  rabbit-go perm grant com.example CAMERA android.permission.RECORD_AUDIO
+  rabbit-go perm grant com.example --group LOCATION
+  rabbit-go perm grant com.example --dangerous`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runPermChange(args, true)
+	},
+}
+
+var permRevokeCmd = &cobra.Command{
+	Use:   "revoke <packageName> [permission...]",
+	Short: "revoke permissions, groups or every dangerous permission",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runPermChange(args, false)
+	},
+}
+
+var permSnapshotCmd = &cobra.Command{
+	Use:   "snapshot <packageName> <name>",
+	Short: "save the runtime permission grant state and flags to a local file",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		s := &strategy.PermSnapshotStrategy{PackageName: args[0], Name: args[1], Dir: permDir, User: permUser}
+		runStrategy(s)
+	},
+}
+
+var permRestoreCmd = &cobra.Command{
+	Use:   "restore <packageName> <name>",
+	Short: "reapply a permission snapshot, user-fixed and other flags included",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		s := &strategy.PermRestoreStrategy{PackageName: args[0], Name: args[1], Dir: permDir, User: permUser}
+		runStrategy(s)
+	},
+}
+
+func runPermChange(args []string, grant bool) {
+	permChange.PackageName = args[0]
+	permChange.Permissions = args[1:]
+	permChange.Grant = grant
+	runStrategy(&permChange)
+}
+
+func init() {
+	permListCmd.Flags().StringVar(&permList.User, "user", "", "user id, defaults to the first user")
+	permListCmd.Flags().BoolVar(&permList.JSON, "json", false, "print as json")
+	for _, cmd := range []*cobra.Command{permGrantCmd, permRevokeCmd} {
+		cmd.Flags().StringSliceVar(&permChange.Groups, "group", nil, "permission groups, e.g. LOCATION,CAMERA")
+		cmd.Flags().BoolVar(&permChange.Dangerous, "dangerous", false, "every requested dangerous permission")
+		cmd.Flags().StringVar(&permChange.User, "user", "", "user id, defaults to the current user")
+	}
+
+	for _, cmd := range []*cobra.Command{permSnapshotCmd, permRestoreCmd} {
+		cmd.Flags().StringVar(&permDir, "dir", os.Getenv("RABBIT_PERM_DIR"), "snapshot directory, defaults to $RABBIT_PERM_DIR or ~/.rabbit-go/permissions")
+		cmd.Flags().StringVar(&permUser, "user", "", "user id, defaults to the first user")
+	}
+
+	permCmd.AddCommand(permListCmd, permGrantCmd, permRevokeCmd, permSnapshotCmd, permRestoreCmd)
+	rootCmd.AddCommand(permCmd)
+}
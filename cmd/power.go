@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"rabbit-go/strategy"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	power      strategy.PowerStrategy
+	powerLight bool
+)
+
+var powerCmd = &cobra.Command{
+	Use:   "power",
+	Short: "simulate doze, App Standby buckets and battery states",
+}
+
+var powerStatusCmd = &cobra.Command{
+	Use:   "status [packageName]",
+	Short: "show battery, doze and the standby bucket of a package",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runPower("status", args)
+	},
+}
+
+var powerIdleCmd = &cobra.Command{
+	Use:   "idle",
+	Short: "unplug the battery and force the device into deep (or --light) doze",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runPower("idle", args)
+	},
+}
+
+var powerStepCmd = &cobra.Command{
+	Use:   "step",
+	Short: "step deep (or --light) doze to its next state",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runPower("step", args)
+	},
+}
+
+var powerUnforceCmd = &cobra.Command{
+	Use:   "unforce",
+	Short: "leave forced idle",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runPower("unforce", args)
+	},
+}
+
+var powerBucketCmd = &cobra.Command{
+	Use:   "bucket <packageName> <bucket>",
+	Short: "set the App Standby bucket (active|working_set|frequent|rare|restricted)",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		power.Bucket = args[1]
+		runPower("bucket", args[:1])
+	},
+}
+
+var powerUnplugCmd = &cobra.Command{
+	Use:   "unplug",
+	Short: "pretend the device runs on battery",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runPower("unplug", args)
+	},
+}
+
+var powerBatteryCmd = &cobra.Command{
+	Use:   "battery <level>",
+	Short: "fake the battery level",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		level, err := strconv.Atoi(args[0])
+		if err != nil {
+			err = fmt.Errorf("invalid battery level: %s", args[0])
+		}
+		power.Action, power.Level = "battery", level
+		runNewStrategy(&power, err)
+	},
+}
+
+var powerResetCmd = &cobra.Command{
+	Use:   "reset [packageName]",
+	Short: "leave forced idle, restore the real battery and move the package back to the active bucket",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runPower("reset", args)
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{powerIdleCmd, powerStepCmd} {
+		cmd.Flags().BoolVar(&powerLight, "light", false, "use light doze instead of deep doze")
+	}
+	powerCmd.PersistentFlags().BoolVar(&power.JSON, "json", false, "print the resulting state as json")
+
+	powerCmd.AddCommand(powerStatusCmd, powerIdleCmd, powerStepCmd, powerUnforceCmd, powerBucketCmd, powerUnplugCmd, powerBatteryCmd, powerResetCmd)
+	rootCmd.AddCommand(powerCmd)
+}
+
+func runPower(action string, args []string) {
+	power.Action = action
+	power.Mode = "deep"
+	if powerLight {
+		power.Mode = "light"
+	}
+	if len(args) > 0 {
+		power.PackageName = args[0]
+	}
+	runStrategy(&power)
+}
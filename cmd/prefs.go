@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"rabbit-go/adb"
+	"rabbit-go/strategy"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var prefs strategy.PrefsStrategy
+
+var prefsCmd = &cobra.Command{
+	Use:   "prefs",
+	Short: "view and edit SharedPreferences of a debuggable app",
+}
+
+var prefsListCmd = &cobra.Command{
+	Use:   "list <packageName> [file]",
+	Short: "list SharedPreferences files, or the entries of a file",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runPrefs("list", args)
+	},
+}
+
+var prefsGetCmd = &cobra.Command{
+	Use:   "get <packageName> <file> <key>",
+	Short: "print the value of a key",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		runPrefs("get", args)
+	},
+}
+
+var prefsSetCmd = &cobra.Command{
+	Use:   "set <packageName> <file> <key> <value>",
+	Short: "set a key, the app is force-stopped first",
+	Example: `  rabbit-go prefs set com.example settings new_home true --type boolean
+  rabbit-go prefs set com.example settings tabs home,feed,me --type set`,
+	Args: cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		runPrefs("set", args)
+	},
+}
+
+var prefsDeleteCmd = &cobra.Command{
+	Use:   "delete <packageName> <file> <key>",
+	Short: "delete a key, the app is force-stopped first",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		runPrefs("delete", args)
+	},
+}
+
+func runPrefs(action string, args []string) {
+	prefs.Action = action
+	prefs.PackageName = args[0]
+	if len(args) > 1 {
+		prefs.File = args[1]
+	}
+	if len(args) > 2 {
+		prefs.Key = args[2]
+	}
+	if len(args) > 3 {
+		prefs.Value = args[3]
+	}
+	runStrategy(&prefs)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{prefsListCmd, prefsGetCmd} {
+		cmd.Flags().BoolVar(&prefs.JSON, "json", false, "print as json")
+	}
+	prefsSetCmd.Flags().StringVarP(&prefs.Type, "type", "t", "", "value type ("+strings.Join(adb.PrefTypes, "|")+"), defaults to the existing type or string")
+
+	prefsCmd.AddCommand(prefsListCmd, prefsGetCmd, prefsSetCmd, prefsDeleteCmd)
+	rootCmd.AddCommand(prefsCmd)
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"rabbit-go/strategy"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var processDeath strategy.ProcessDeathStrategy
+
+var processDeathCmd = &cobra.Command{
+	Use:   "process-death <packageName>",
+	Short: "kill the app process in the background but keep its task, to test state restoration",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		processDeath.PackageName = args[0]
+		runStrategy(&processDeath)
+	},
+}
+
+func init() {
+	processDeathCmd.Flags().BoolVar(&processDeath.Relaunch, "relaunch", false, "bring the task back and check the same activity was restored")
+	processDeathCmd.Flags().DurationVar(&processDeath.Delay, "delay", time.Second, "wait time after going home and after relaunching")
+	rootCmd.AddCommand(processDeathCmd)
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"rabbit-go/strategy"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var recordFlow strategy.RecordFlowStrategy
+
+var recordFlowCmd = &cobra.Command{
+	Use:   "record-flow",
+	Short: "record activity and fragment transitions and export them as json, mermaid, graphviz and timeline",
+	Run: func(cmd *cobra.Command, args []string) {
+		runStrategy(&recordFlow)
+	},
+}
+
+func init() {
+	recordFlowCmd.Flags().StringVarP(&recordFlow.PackageName, "package", "p", "", "only record screens of this package")
+	recordFlowCmd.Flags().DurationVar(&recordFlow.Interval, "interval", time.Second, "polling interval")
+	recordFlowCmd.Flags().DurationVar(&recordFlow.Duration, "duration", 0, "stop after this duration, default until Ctrl + C")
+	recordFlowCmd.Flags().StringVarP(&recordFlow.Output, "output", "o", "", "output file prefix, default <timestamp>_flow")
+	rootCmd.AddCommand(recordFlowCmd)
+}
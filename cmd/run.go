@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+)
+
+type runnable interface {
+	Run() error
+}
+
+// runStrategy runs s and exits with status 1 when it fails
+func runStrategy(s runnable) {
+	runNewStrategy(s, nil)
+}
+
+// runNewStrategy runs a strategy returned by a constructor, err is the constructor error
+func runNewStrategy(s runnable, err error) {
+	if err == nil {
+		err = s.Run()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"rabbit-go/config"
+	"rabbit-go/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serviceConfig     config.IntentConfig
+	serviceForeground bool
+	serviceList       strategy.ServiceListStrategy
+	serviceStop       strategy.ServiceStopStrategy
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "start, stop and list services",
+}
+
+var serviceStartCmd = &cobra.Command{
+	Use:     "start [component]",
+	Short:   "start a service with an intent",
+	Example: `  rabbit-go service start com.example/.SyncService --es mode=full`,
+	Args:    cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 {
+			serviceConfig.Component = args[0]
+		}
+		s, err := newIntentStrategy("service", serviceConfig)
+		if err == nil {
+			s.Foreground = serviceForeground
+		}
+		runNewStrategy(s, err)
+	},
+}
+
+var serviceStopCmd = &cobra.Command{
+	Use:   "stop <component>",
+	Short: "stop a started service",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		serviceStop.Component = args[0]
+		runStrategy(&serviceStop)
+	},
+}
+
+var serviceListCmd = &cobra.Command{
+	Use:   "list [packageName]",
+	Short: "list the running services, of every package when none is given",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			serviceList.PackageName = args[0]
+		}
+		runStrategy(&serviceList)
+	},
+}
+
+func init() {
+	addIntentFlags(serviceStartCmd, &serviceConfig)
+	serviceStartCmd.Flags().BoolVar(&serviceForeground, "foreground", false, "start with start-foreground-service, Android 8+")
+	serviceStopCmd.Flags().StringVar(&serviceStop.User, "user", "", "target user id")
+	serviceListCmd.Flags().BoolVar(&serviceList.JSON, "json", false, "print as json")
+
+	serviceCmd.AddCommand(serviceStartCmd, serviceStopCmd, serviceListCmd)
+	rootCmd.AddCommand(serviceCmd)
+}
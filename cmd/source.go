@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os"
+	"rabbit-go/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	sourceRoot   string
+	sourceEditor string
+	sourceOpen   bool
+)
+
+var sourceCmd = &cobra.Command{
+	Use:   "source [className...]",
+	Short: "find the local source files of the current activity and fragments",
+	Long: `Find the .kt/.java files declaring the resumed activity and its visible fragments.
+Class names can also be passed explicitly. The project root defaults to $RABBIT_SOURCE_ROOT
+or the current directory, the editor to $RABBIT_EDITOR. The editor command may contain
+{file} and {line} placeholders, otherwise "path:line" is appended, e.g. "code -g" or
+"idea --line {line} {file}".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		s := &strategy.SourceStrategy{
+			Root:    sourceRoot,
+			Editor:  sourceEditor,
+			Open:    sourceOpen,
+			Classes: args,
+		}
+		runStrategy(s)
+	},
+}
+
+func init() {
+	sourceCmd.Flags().StringVar(&sourceRoot, "root", os.Getenv("RABBIT_SOURCE_ROOT"), "project root to search")
+	sourceCmd.Flags().StringVar(&sourceEditor, "editor", os.Getenv("RABBIT_EDITOR"), "editor command used by --open")
+	sourceCmd.Flags().BoolVarP(&sourceOpen, "open", "o", false, "open the innermost screen in the editor")
+	rootCmd.AddCommand(sourceCmd)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"rabbit-go/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	pull strategy.PullStrategy
+	push strategy.PushStrategy
+	ls   strategy.LsStrategy
+)
+
+var pullCmd = &cobra.Command{
+	Use:   "pull <remote>... [local]",
+	Short: "copy files or directories from the device, keeping their mtimes",
+	Example: `  rabbit-go pull /sdcard/Download
+  rabbit-go pull /sdcard/DCIM/Camera ./photos --skip-existing`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pull.Remotes = args
+		if len(args) > 1 {
+			pull.Remotes, pull.Local = args[:len(args)-1], args[len(args)-1]
+		}
+		runStrategy(&pull)
+	},
+}
+
+var pushCmd = &cobra.Command{
+	Use:   "push <local>... <remote>",
+	Short: "copy files or directories to the device, keeping their mtimes",
+	Example: `  rabbit-go push build/fixtures /sdcard/fixtures
+  rabbit-go push a.txt b.txt /data/local/tmp/`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		push.Locals, push.Remote = args[:len(args)-1], args[len(args)-1]
+		runStrategy(&push)
+	},
+}
+
+var lsCmd = &cobra.Command{
+	Use:   "ls <remote>",
+	Short: "list a device directory with mode, size and mtime",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ls.Remote = args[0]
+		runStrategy(&ls)
+	},
+}
+
+func init() {
+	pullCmd.Flags().BoolVar(&pull.SkipExisting, "skip-existing", false, "skip files whose local size and mtime already match, to resume a failed pull")
+	pushCmd.Flags().BoolVar(&push.SkipExisting, "skip-existing", false, "skip files whose remote size and mtime already match, to resume a failed push")
+	lsCmd.Flags().BoolVar(&ls.JSON, "json", false, "print as json")
+	rootCmd.AddCommand(pullCmd, pushCmd, lsCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"rabbit-go/adb"
+	"rabbit-go/strategy"
+	"regexp"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	uiJSON     bool
+	uiSelector adb.UISelector
+	uiRegex    string
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "dump and query the ui hierarchy with uiautomator",
+}
+
+var uiDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "print the ui hierarchy of the foreground window",
+	Run: func(cmd *cobra.Command, args []string) {
+		s := &strategy.UIDumpStrategy{JSON: uiJSON}
+		runStrategy(s)
+	},
+}
+
+var uiFindCmd = &cobra.Command{
+	Use:   "find",
+	Short: "find ui elements by id, text, content description, class or regex",
+	Run: func(cmd *cobra.Command, args []string) {
+		selector, err := buildUISelector()
+		runNewStrategy(&strategy.UIFindStrategy{Selector: selector, JSON: uiJSON}, err)
+	},
+}
+
+func init() {
+	uiCmd.PersistentFlags().BoolVar(&uiJSON, "json", false, "print as json")
+	addUISelectorFlags(uiFindCmd)
+
+	uiCmd.AddCommand(uiDumpCmd, uiFindCmd)
+	rootCmd.AddCommand(uiCmd)
+}
+
+// addUISelectorFlags registers the element selector flags on cmd
+func addUISelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&uiSelector.ID, "id", "", "resource id, with or without the package prefix")
+	cmd.Flags().StringVar(&uiSelector.Text, "text", "", "exact text")
+	cmd.Flags().StringVar(&uiSelector.Desc, "desc", "", "exact content description")
+	cmd.Flags().StringVar(&uiSelector.Class, "class", "", "class name, e.g. Button or android.widget.Button")
+	cmd.Flags().StringVar(&uiRegex, "regex", "", "regex matched against text, content description and resource id")
+	cmd.Flags().BoolVar(&uiSelector.Clickable, "clickable", false, "only clickable elements")
+}
+
+func buildUISelector() (*adb.UISelector, error) {
+	selector := uiSelector
+	if uiRegex != "" {
+		re, err := regexp.Compile(uiRegex)
+		if err != nil {
+			return nil, err
+		}
+		selector.Regex = re
+	}
+	return &selector, nil
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"rabbit-go/strategy"
+
+	"github.com/spf13/cobra"
+)
+
+var windowAll bool
+
+var windowCmd = &cobra.Command{
+	Use:   "window",
+	Short: "print focused window, dialogs, overlays and keyboard state",
+	Run: func(cmd *cobra.Command, args []string) {
+		s := &strategy.TopWindowStrategy{All: windowAll}
+		runStrategy(s)
+	},
+}
+
+func init() {
+	windowCmd.Flags().BoolVarP(&windowAll, "all", "a", false, "print all windows instead of the visible ones")
+	rootCmd.AddCommand(windowCmd)
+}
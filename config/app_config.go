@@ -9,4 +9,6 @@ type AppConfig struct {
 	RestartPackageName             string
 	StartAppDetailPackageName      string
 	ExportPackageName              string
+	ExportDir                      string
+	ExportAsApks                   bool
 }
@@ -0,0 +1,20 @@
+package config
+
+type IntentConfig struct {
+	Target       string
+	Component    string
+	Action       string
+	Data         string
+	MimeType     string
+	Package      string
+	Categories   []string
+	Flags        []string
+	StringExtras []string
+	IntExtras    []string
+	BoolExtras   []string
+	LongExtras   []string
+	ArrayExtras  []string
+	ExtrasFile   string
+	User         string
+	DryRun       bool
+}
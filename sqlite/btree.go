@@ -0,0 +1,145 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	pageInteriorTable = 0x05
+	pageLeafTable     = 0x0d
+)
+
+// errStop ends a table scan early.
+var errStop = errors.New("stop")
+
+// corrupt reports a malformed database structure instead of indexing out of bounds.
+func corrupt(format string, args ...any) error {
+	return fmt.Errorf(format+", the database is corrupt", args...)
+}
+
+// scanTable visits every row of the table b-tree rooted at root in rowid order.
+func (db *DB) scanTable(root uint32, fn func(rowid int64, payload []byte) error) error {
+	return db.scanPage(root, fn, 0, make(map[uint32]bool))
+}
+
+func (db *DB) scanPage(n uint32, fn func(rowid int64, payload []byte) error, depth int, visited map[uint32]bool) error {
+	if depth > 64 {
+		return errors.New("b-tree too deep, the database is corrupt")
+	}
+	// a page reachable twice would be a cycle or a shared subtree
+	if visited[n] {
+		return corrupt("page %d is referenced twice", n)
+	}
+	visited[n] = true
+	page, err := db.page(n)
+	if err != nil {
+		return err
+	}
+	offset := 0
+	if n == 1 {
+		offset = 100
+	}
+	header := page[offset:]
+	headerSize := 8
+	if header[0] == pageInteriorTable {
+		headerSize = 12
+	}
+	if len(header) < headerSize {
+		return corrupt("page %d header out of bounds", n)
+	}
+	cellCount := int(binary.BigEndian.Uint16(header[3:5]))
+	cells := header[headerSize:]
+	if cellCount*2 > len(cells) {
+		return corrupt("page %d has %d cells, more than fit in the page", n, cellCount)
+	}
+
+	switch header[0] {
+	case pageLeafTable:
+		for i := 0; i < cellCount; i++ {
+			ptr := int(binary.BigEndian.Uint16(cells[i*2:]))
+			if ptr < offset+headerSize || ptr >= len(page) {
+				return corrupt("cell %d of page %d out of bounds", i, n)
+			}
+			size, k := readVarint(page[ptr:])
+			rowid, m := readVarint(page[ptr+k:])
+			if ptr+k+m > len(page) || size > uint64(len(db.data)+len(db.wal)*db.pageSize) {
+				return corrupt("cell %d of page %d out of bounds", i, n)
+			}
+			payload, err := db.readPayload(page, ptr+k+m, int(size))
+			if err != nil {
+				return err
+			}
+			if err := fn(int64(rowid), payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	case pageInteriorTable:
+		for i := 0; i < cellCount; i++ {
+			ptr := int(binary.BigEndian.Uint16(cells[i*2:]))
+			if ptr < offset+headerSize || ptr+4 > len(page) {
+				return corrupt("cell %d of page %d out of bounds", i, n)
+			}
+			child := binary.BigEndian.Uint32(page[ptr:])
+			if err := db.scanPage(child, fn, depth+1, visited); err != nil {
+				return err
+			}
+		}
+		return db.scanPage(binary.BigEndian.Uint32(header[8:12]), fn, depth+1, visited)
+	}
+	return fmt.Errorf("page %d is not a table b-tree page (type %d)", n, header[0])
+}
+
+// readPayload returns the payload of a leaf table cell, following its overflow pages.
+func (db *DB) readPayload(page []byte, start, size int) ([]byte, error) {
+	u := db.usableSize
+	maxLocal := u - 35
+	if size <= maxLocal {
+		if start+size > len(page) {
+			return nil, corrupt("cell out of page bounds")
+		}
+		return page[start : start+size], nil
+	}
+
+	minLocal := (u-12)*32/255 - 23
+	local := minLocal + (size-minLocal)%(u-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	if start+local+4 > len(page) {
+		return nil, corrupt("cell out of page bounds")
+	}
+	payload := make([]byte, 0, size)
+	payload = append(payload, page[start:start+local]...)
+	next := binary.BigEndian.Uint32(page[start+local:])
+	for next != 0 && len(payload) < size {
+		overflow, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		n := min(size-len(payload), u-4)
+		payload = append(payload, overflow[4:4+n]...)
+		next = binary.BigEndian.Uint32(overflow[0:4])
+	}
+	if len(payload) != size {
+		return nil, corrupt("truncated overflow chain")
+	}
+	return payload, nil
+}
+
+// readVarint decodes a sqlite big-endian varint of up to 9 bytes.
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 8 && i < len(b); i++ {
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	if len(b) < 9 {
+		return v, len(b)
+	}
+	return v<<8 | uint64(b[8]), 9
+}
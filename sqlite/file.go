@@ -0,0 +1,119 @@
+// Package sqlite is a read-only reader of SQLite database files with a small SELECT subset,
+// enough to inspect app databases pulled from a device without cgo or a sqlite3 binary.
+package sqlite
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	fileHeader    = "SQLite format 3\x00"
+	walHeaderSize = 32
+	walFrameSize  = 24
+)
+
+// DB is an opened database file with its committed WAL frames applied.
+type DB struct {
+	data       []byte
+	pageSize   int
+	usableSize int
+	encoding   uint32
+	wal        map[uint32][]byte
+	pageCount  uint32
+}
+
+// Open reads a database file, path-wal is applied when it exists.
+func Open(path string) (*DB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 100 || string(data[:16]) != fileHeader {
+		return nil, fmt.Errorf("%s is not a sqlite database", path)
+	}
+
+	db := &DB{data: data}
+	db.pageSize = int(binary.BigEndian.Uint16(data[16:18]))
+	if db.pageSize == 1 {
+		db.pageSize = 65536
+	}
+	if db.pageSize < 512 || db.pageSize&(db.pageSize-1) != 0 {
+		return nil, corrupt("invalid page size %d", db.pageSize)
+	}
+	db.usableSize = db.pageSize - int(data[20])
+	if db.usableSize < 480 {
+		return nil, corrupt("invalid usable page size %d", db.usableSize)
+	}
+	db.encoding = binary.BigEndian.Uint32(data[56:60])
+	db.pageCount = uint32(len(data) / db.pageSize)
+
+	if wal, err := os.ReadFile(path + "-wal"); err == nil {
+		if err := db.applyWAL(wal); err != nil {
+			return nil, err
+		}
+	}
+	if db.encoding > 1 {
+		return nil, errors.New("only utf-8 databases are supported")
+	}
+	return db, nil
+}
+
+// applyWAL keeps the latest version of every page written by a committed transaction.
+// Frames after the last commit or with stale salts are ignored like sqlite does.
+func (db *DB) applyWAL(wal []byte) error {
+	if len(wal) < walHeaderSize {
+		return nil
+	}
+	magic := binary.BigEndian.Uint32(wal[0:4])
+	if magic != 0x377f0682 && magic != 0x377f0683 {
+		return errors.New("invalid wal file")
+	}
+	pageSize := int(binary.BigEndian.Uint32(wal[8:12]))
+	if pageSize != db.pageSize {
+		return fmt.Errorf("wal page size %d does not match database page size %d", pageSize, db.pageSize)
+	}
+	salt1, salt2 := binary.BigEndian.Uint32(wal[16:20]), binary.BigEndian.Uint32(wal[20:24])
+
+	committed := make(map[uint32][]byte)
+	pending := make(map[uint32][]byte)
+	for off := walHeaderSize; off+walFrameSize+pageSize <= len(wal); off += walFrameSize + pageSize {
+		frame := wal[off : off+walFrameSize]
+		if binary.BigEndian.Uint32(frame[8:12]) != salt1 || binary.BigEndian.Uint32(frame[12:16]) != salt2 {
+			break
+		}
+		pageNo := binary.BigEndian.Uint32(frame[0:4])
+		pending[pageNo] = wal[off+walFrameSize : off+walFrameSize+pageSize]
+		if size := binary.BigEndian.Uint32(frame[4:8]); size != 0 {
+			for n, page := range pending {
+				committed[n] = page
+			}
+			pending = make(map[uint32][]byte)
+			db.pageCount = size
+		}
+	}
+	if len(committed) > 0 {
+		db.wal = committed
+		if page1, ok := committed[1]; ok {
+			db.encoding = binary.BigEndian.Uint32(page1[56:60])
+		}
+	}
+	return nil
+}
+
+// page returns page n, pages are numbered from 1.
+func (db *DB) page(n uint32) ([]byte, error) {
+	if page, ok := db.wal[n]; ok {
+		return page, nil
+	}
+	if n == 0 || n > db.pageCount {
+		return nil, fmt.Errorf("page %d out of range", n)
+	}
+	start := int(n-1) * db.pageSize
+	if start+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("page %d out of range", n)
+	}
+	return db.data[start : start+db.pageSize], nil
+}
@@ -0,0 +1,906 @@
+package sqlite
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Result is the output of a query.
+type Result struct {
+	Columns []string
+	Rows    [][]Value
+}
+
+// Query runs a SELECT statement. The supported subset is
+//
+//	SELECT [DISTINCT] * | expr [AS alias], ... | COUNT(*) FROM table
+//	[WHERE expr] [ORDER BY expr [ASC|DESC], ...] [LIMIT n [OFFSET m]]
+//
+// where expr supports columns, rowid, literals, comparisons, AND, OR, NOT,
+// LIKE, IN, BETWEEN and IS [NOT] NULL.
+func (db *DB) Query(sql string) (*Result, error) {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	stmt, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	table, err := db.Table(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+	if table.WithoutRowid {
+		return nil, fmt.Errorf("WITHOUT ROWID table %s is not supported", table.Name)
+	}
+	return stmt.run(db, table)
+}
+
+type resultColumn struct {
+	expr  expr
+	name  string
+	star  bool
+	count bool
+}
+
+type orderTerm struct {
+	expr expr
+	desc bool
+}
+
+type selectStmt struct {
+	distinct bool
+	columns  []resultColumn
+	table    string
+	where    expr
+	orderBy  []orderTerm
+	limit    int
+	offset   int
+}
+
+type row struct {
+	table  *Table
+	rowid  int64
+	values []Value
+}
+
+func (r *row) column(name string) (Value, error) {
+	for i, c := range r.table.Columns {
+		if strings.EqualFold(c, name) {
+			if i == r.table.RowidAlias {
+				return r.rowid, nil
+			}
+			if i < len(r.values) {
+				return r.values[i], nil
+			}
+			// columns added by ALTER TABLE are missing from older records
+			return nil, nil
+		}
+	}
+	switch strings.ToLower(name) {
+	case "rowid", "_rowid_", "oid":
+		return r.rowid, nil
+	}
+	return nil, fmt.Errorf("no such column: %s", name)
+}
+
+func (s *selectStmt) run(db *DB, table *Table) (*Result, error) {
+	result := &Result{}
+	isCount := len(s.columns) == 1 && s.columns[0].count
+	for _, c := range s.columns {
+		if c.star {
+			result.Columns = append(result.Columns, table.Columns...)
+		} else {
+			result.Columns = append(result.Columns, c.name)
+		}
+	}
+
+	type sortedRow struct {
+		keys   []Value
+		values []Value
+	}
+	var rows []sortedRow
+	count := int64(0)
+	seen := make(map[string]bool)
+
+	err := db.scanTable(table.RootPage, func(rowid int64, payload []byte) error {
+		values, err := decodeRecord(payload)
+		if err != nil {
+			return err
+		}
+		r := &row{table: table, rowid: rowid, values: values}
+		if s.where != nil {
+			v, err := s.where.eval(r)
+			if err != nil {
+				return err
+			}
+			if !truthy(v) {
+				return nil
+			}
+		}
+		if isCount {
+			count++
+			return nil
+		}
+
+		var out []Value
+		for _, c := range s.columns {
+			if c.star {
+				for i := range table.Columns {
+					v, _ := r.column(table.Columns[i])
+					out = append(out, v)
+				}
+				continue
+			}
+			if c.count {
+				return fmt.Errorf("COUNT(*) must be the only result column")
+			}
+			v, err := c.expr.eval(r)
+			if err != nil {
+				return err
+			}
+			out = append(out, v)
+		}
+		if s.distinct {
+			key := fmt.Sprintf("%#v", out)
+			if seen[key] {
+				return nil
+			}
+			seen[key] = true
+		}
+
+		var keys []Value
+		for _, term := range s.orderBy {
+			v, err := term.expr.eval(r)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, v)
+		}
+		rows = append(rows, sortedRow{keys: keys, values: out})
+		// without ORDER BY the scan can stop once the limit is reached
+		if len(s.orderBy) == 0 && s.limit >= 0 && len(rows) >= s.offset+s.limit {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+
+	if isCount {
+		result.Rows = [][]Value{{count}}
+		return result, nil
+	}
+
+	if len(s.orderBy) > 0 {
+		sort.SliceStable(rows, func(i, j int) bool {
+			for k, term := range s.orderBy {
+				c := compare(rows[i].keys[k], rows[j].keys[k])
+				if c != 0 {
+					return (c < 0) != term.desc
+				}
+			}
+			return false
+		})
+	}
+	start := min(s.offset, len(rows))
+	end := len(rows)
+	if s.limit >= 0 {
+		end = min(start+s.limit, len(rows))
+	}
+	for _, r := range rows[start:end] {
+		result.Rows = append(result.Rows, r.values)
+	}
+	return result, nil
+}
+
+// expressions
+
+type expr interface {
+	eval(r *row) (Value, error)
+}
+
+type literal struct{ value Value }
+
+func (e literal) eval(*row) (Value, error) { return e.value, nil }
+
+type columnRef struct{ name string }
+
+func (e columnRef) eval(r *row) (Value, error) { return r.column(e.name) }
+
+type unaryExpr struct {
+	op string
+	x  expr
+}
+
+func (e unaryExpr) eval(r *row) (Value, error) {
+	v, err := e.x.eval(r)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	switch e.op {
+	case "NOT":
+		return boolValue(!truthy(v)), nil
+	case "-":
+		switch n := v.(type) {
+		case int64:
+			return -n, nil
+		case float64:
+			return -n, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid operand for %s", e.op)
+}
+
+type binaryExpr struct {
+	op   string
+	x, y expr
+}
+
+func (e binaryExpr) eval(r *row) (Value, error) {
+	x, err := e.x.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "AND":
+		if x != nil && !truthy(x) {
+			return int64(0), nil
+		}
+	case "OR":
+		if x != nil && truthy(x) {
+			return int64(1), nil
+		}
+	}
+	y, err := e.y.eval(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.op {
+	case "AND":
+		if y != nil && !truthy(y) {
+			return int64(0), nil
+		}
+		if x == nil || y == nil {
+			return nil, nil
+		}
+		return int64(1), nil
+	case "OR":
+		if y != nil && truthy(y) {
+			return int64(1), nil
+		}
+		if x == nil || y == nil {
+			return nil, nil
+		}
+		return int64(0), nil
+	}
+
+	if x == nil || y == nil {
+		return nil, nil
+	}
+	switch e.op {
+	case "LIKE":
+		return boolValue(like(toText(y), toText(x))), nil
+	}
+	c := compare(x, y)
+	switch e.op {
+	case "=":
+		return boolValue(c == 0), nil
+	case "!=":
+		return boolValue(c != 0), nil
+	case "<":
+		return boolValue(c < 0), nil
+	case "<=":
+		return boolValue(c <= 0), nil
+	case ">":
+		return boolValue(c > 0), nil
+	case ">=":
+		return boolValue(c >= 0), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", e.op)
+}
+
+type isNull struct {
+	x   expr
+	not bool
+}
+
+func (e isNull) eval(r *row) (Value, error) {
+	v, err := e.x.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	return boolValue((v == nil) != e.not), nil
+}
+
+type inList struct {
+	x    expr
+	list []expr
+	not  bool
+}
+
+func (e inList) eval(r *row) (Value, error) {
+	v, err := e.x.eval(r)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	for _, item := range e.list {
+		w, err := item.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		if w != nil && compare(v, w) == 0 {
+			return boolValue(!e.not), nil
+		}
+	}
+	return boolValue(e.not), nil
+}
+
+func boolValue(b bool) Value {
+	if b {
+		return int64(1)
+	}
+	return int64(0)
+}
+
+func truthy(v Value) bool {
+	switch n := v.(type) {
+	case int64:
+		return n != 0
+	case float64:
+		return n != 0
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f != 0
+	}
+	return false
+}
+
+// compare orders values like sqlite: NULL < numbers < text < blob.
+// Text that looks like a number is compared numerically with numbers.
+func compare(x, y Value) int {
+	xf, xNum := numeric(x)
+	yf, yNum := numeric(y)
+	if xNum && yNum {
+		switch {
+		case xf < yf:
+			return -1
+		case xf > yf:
+			return 1
+		}
+		return 0
+	}
+	if rx, ry := typeRank(x), typeRank(y); rx != ry {
+		return rx - ry
+	}
+	switch a := x.(type) {
+	case string:
+		return strings.Compare(a, y.(string))
+	case []byte:
+		return bytes.Compare(a, y.([]byte))
+	}
+	return 0
+}
+
+func numeric(v Value) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func typeRank(v Value) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case int64, float64:
+		return 1
+	case string:
+		return 2
+	}
+	return 3
+}
+
+func toText(v Value) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	}
+	return FormatValue(v)
+}
+
+// like matches an ASCII case-insensitive LIKE pattern with % and _.
+func like(pattern, s string) bool {
+	p, t := []rune(strings.ToLower(pattern)), []rune(strings.ToLower(s))
+	var match func(i, j int) bool
+	match = func(i, j int) bool {
+		for i < len(p) {
+			switch p[i] {
+			case '%':
+				for k := j; k <= len(t); k++ {
+					if match(i+1, k) {
+						return true
+					}
+				}
+				return false
+			case '_':
+				if j >= len(t) {
+					return false
+				}
+			default:
+				if j >= len(t) || p[i] != t[j] {
+					return false
+				}
+			}
+			i++
+			j++
+		}
+		return j == len(t)
+	}
+	return match(0, 0)
+}
+
+// FormatValue renders a value for display, blobs as their size.
+func FormatValue(v Value) string {
+	switch t := v.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64)
+	case string:
+		return t
+	case []byte:
+		return fmt.Sprintf("<blob %d bytes>", len(t))
+	}
+	return fmt.Sprint(v)
+}
+
+// tokenizer
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokKeyword
+	tokNumber
+	tokString
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+var keywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "FROM": true, "WHERE": true, "ORDER": true, "BY": true,
+	"ASC": true, "DESC": true, "LIMIT": true, "OFFSET": true, "AND": true, "OR": true, "NOT": true,
+	"LIKE": true, "IN": true, "IS": true, "NULL": true, "BETWEEN": true, "AS": true,
+}
+
+func tokenize(sql string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(sql); j++ {
+				if sql[j] == '\'' {
+					if j+1 < len(sql) && sql[j+1] == '\'' {
+						sb.WriteByte('\'')
+						j++
+						continue
+					}
+					break
+				}
+				sb.WriteByte(sql[j])
+			}
+			if j >= len(sql) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, token{tokString, sb.String()})
+			i = j + 1
+		case c == '"' || c == '`' || c == '[':
+			end := map[byte]byte{'"': '"', '`': '`', '[': ']'}[c]
+			j := strings.IndexByte(sql[i+1:], end)
+			if j == -1 {
+				return nil, fmt.Errorf("unterminated identifier")
+			}
+			tokens = append(tokens, token{tokIdent, sql[i+1 : i+1+j]})
+			i += j + 2
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			j := i
+			for j < len(sql) && (sql[j] >= '0' && sql[j] <= '9' || sql[j] == '.' || sql[j] == 'e' || sql[j] == 'E' ||
+				(sql[j] == '-' || sql[j] == '+') && (sql[j-1] == 'e' || sql[j-1] == 'E')) {
+				j++
+			}
+			tokens = append(tokens, token{tokNumber, sql[i:j]})
+			i = j
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(sql) && (sql[j] == '_' || sql[j] == '$' || unicode.IsLetter(rune(sql[j])) || unicode.IsDigit(rune(sql[j]))) {
+				j++
+			}
+			word := sql[i:j]
+			if keywords[strings.ToUpper(word)] {
+				tokens = append(tokens, token{tokKeyword, strings.ToUpper(word)})
+			} else {
+				tokens = append(tokens, token{tokIdent, word})
+			}
+			i = j
+		default:
+			two := ""
+			if i+1 < len(sql) {
+				two = sql[i : i+2]
+			}
+			switch two {
+			case "<=", ">=", "!=", "<>", "==":
+				tokens = append(tokens, token{tokSymbol, two})
+				i += 2
+				continue
+			}
+			if !strings.ContainsRune("*,()=<>;-", rune(c)) {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, token{tokSymbol, string(c)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// parser
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return token{kind: tokSymbol}
+}
+
+func (p *parser) accept(kind tokenKind, text string) bool {
+	t := p.peek()
+	if t.kind == kind && t.text == text && p.pos < len(p.tokens) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	if !p.accept(kind, text) {
+		return fmt.Errorf("expected %s near %q", text, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) parseSelect() (*selectStmt, error) {
+	stmt := &selectStmt{limit: -1}
+	if err := p.expect(tokKeyword, "SELECT"); err != nil {
+		return nil, fmt.Errorf("only SELECT statements are supported")
+	}
+	stmt.distinct = p.accept(tokKeyword, "DISTINCT")
+
+	for {
+		column, err := p.parseResultColumn()
+		if err != nil {
+			return nil, err
+		}
+		stmt.columns = append(stmt.columns, column)
+		if !p.accept(tokSymbol, ",") {
+			break
+		}
+	}
+
+	if err := p.expect(tokKeyword, "FROM"); err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokIdent {
+		return nil, fmt.Errorf("expected table name near %q", t.text)
+	}
+	stmt.table = t.text
+	p.pos++
+
+	if p.accept(tokKeyword, "WHERE") {
+		where, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		stmt.where = where
+	}
+	if p.accept(tokKeyword, "ORDER") {
+		if err := p.expect(tokKeyword, "BY"); err != nil {
+			return nil, err
+		}
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			e = stmt.resolveOrderTerm(e)
+			term := orderTerm{expr: e}
+			if p.accept(tokKeyword, "DESC") {
+				term.desc = true
+			} else {
+				p.accept(tokKeyword, "ASC")
+			}
+			stmt.orderBy = append(stmt.orderBy, term)
+			if !p.accept(tokSymbol, ",") {
+				break
+			}
+		}
+	}
+	if p.accept(tokKeyword, "LIMIT") {
+		n, err := p.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		stmt.limit = n
+		if p.accept(tokKeyword, "OFFSET") {
+			if stmt.offset, err = p.parseInt(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.accept(tokSymbol, ";")
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unsupported syntax near %q", p.peek().text)
+	}
+	return stmt, nil
+}
+
+// resolveOrderTerm maps ORDER BY 2 to the second result column and an alias to its expression.
+func (s *selectStmt) resolveOrderTerm(e expr) expr {
+	switch t := e.(type) {
+	case literal:
+		if n, ok := t.value.(int64); ok && n >= 1 && int(n) <= len(s.columns) && s.columns[n-1].expr != nil {
+			return s.columns[n-1].expr
+		}
+	case columnRef:
+		for _, c := range s.columns {
+			if c.expr != nil && strings.EqualFold(c.name, t.name) {
+				return c.expr
+			}
+		}
+	}
+	return e
+}
+
+func (p *parser) parseResultColumn() (resultColumn, error) {
+	if p.accept(tokSymbol, "*") {
+		return resultColumn{star: true}, nil
+	}
+	// COUNT is not a keyword, Room entities may well have a count column
+	if t := p.peek(); t.kind == tokIdent && strings.EqualFold(t.text, "COUNT") && p.pos+1 < len(p.tokens) &&
+		p.tokens[p.pos+1].kind == tokSymbol && p.tokens[p.pos+1].text == "(" {
+		p.pos += 2
+		if err := p.expect(tokSymbol, "*"); err != nil {
+			return resultColumn{}, fmt.Errorf("only COUNT(*) is supported")
+		}
+		if err := p.expect(tokSymbol, ")"); err != nil {
+			return resultColumn{}, err
+		}
+		column := resultColumn{count: true, name: "COUNT(*)"}
+		column.name = p.parseAlias(column.name)
+		return column, nil
+	}
+
+	start := p.pos
+	e, err := p.parseExpr()
+	if err != nil {
+		return resultColumn{}, err
+	}
+	var parts []string
+	for _, t := range p.tokens[start:p.pos] {
+		parts = append(parts, t.text)
+	}
+	name := strings.Join(parts, " ")
+	if ref, ok := e.(columnRef); ok {
+		name = ref.name
+	}
+	return resultColumn{expr: e, name: p.parseAlias(name)}, nil
+}
+
+func (p *parser) parseAlias(name string) string {
+	if p.accept(tokKeyword, "AS") {
+		if t := p.peek(); t.kind == tokIdent || t.kind == tokString {
+			p.pos++
+			return t.text
+		}
+		return name
+	}
+	if t := p.peek(); t.kind == tokIdent {
+		p.pos++
+		return t.text
+	}
+	return name
+}
+
+func (p *parser) parseInt() (int, error) {
+	t := p.peek()
+	n, err := strconv.Atoi(t.text)
+	if t.kind != tokNumber || err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a number near %q", t.text)
+	}
+	p.pos++
+	return n, nil
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (expr, error) {
+	x, err := p.parseAnd()
+	for err == nil && p.accept(tokKeyword, "OR") {
+		var y expr
+		if y, err = p.parseAnd(); err == nil {
+			x = binaryExpr{op: "OR", x: x, y: y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	x, err := p.parseNot()
+	for err == nil && p.accept(tokKeyword, "AND") {
+		var y expr
+		if y, err = p.parseNot(); err == nil {
+			x = binaryExpr{op: "AND", x: x, y: y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.accept(tokKeyword, "NOT") {
+		x, err := p.parseNot()
+		return unaryExpr{op: "NOT", x: x}, err
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind == tokSymbol {
+		op := map[string]string{"=": "=", "==": "=", "!=": "!=", "<>": "!=", "<": "<", "<=": "<=", ">": ">", ">=": ">="}[t.text]
+		if op != "" {
+			p.pos++
+			y, err := p.parsePrimary()
+			return binaryExpr{op: op, x: x, y: y}, err
+		}
+	}
+
+	if p.accept(tokKeyword, "IS") {
+		not := p.accept(tokKeyword, "NOT")
+		if err := p.expect(tokKeyword, "NULL"); err != nil {
+			return nil, err
+		}
+		return isNull{x: x, not: not}, nil
+	}
+
+	not := p.accept(tokKeyword, "NOT")
+	var e expr
+	switch {
+	case p.accept(tokKeyword, "LIKE"):
+		y, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		e = binaryExpr{op: "LIKE", x: x, y: y}
+	case p.accept(tokKeyword, "IN"):
+		if err := p.expect(tokSymbol, "("); err != nil {
+			return nil, err
+		}
+		in := inList{x: x}
+		for {
+			item, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, item)
+			if !p.accept(tokSymbol, ",") {
+				break
+			}
+		}
+		if err := p.expect(tokSymbol, ")"); err != nil {
+			return nil, err
+		}
+		e = in
+	case p.accept(tokKeyword, "BETWEEN"):
+		low, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokKeyword, "AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		e = binaryExpr{op: "AND", x: binaryExpr{op: ">=", x: x, y: low}, y: binaryExpr{op: "<=", x: x, y: high}}
+	default:
+		if not {
+			return nil, fmt.Errorf("unexpected NOT near %q", p.peek().text)
+		}
+		return x, nil
+	}
+	if not {
+		e = unaryExpr{op: "NOT", x: e}
+	}
+	return e, nil
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokSymbol && t.text == "(":
+		p.pos++
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(tokSymbol, ")")
+	case t.kind == tokSymbol && t.text == "-":
+		p.pos++
+		x, err := p.parsePrimary()
+		return unaryExpr{op: "-", x: x}, err
+	case t.kind == tokNumber:
+		p.pos++
+		if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return literal{n}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", t.text)
+		}
+		return literal{f}, nil
+	case t.kind == tokString:
+		p.pos++
+		return literal{t.text}, nil
+	case t.kind == tokKeyword && t.text == "NULL":
+		p.pos++
+		return literal{nil}, nil
+	case t.kind == tokIdent:
+		p.pos++
+		return columnRef{name: t.text}, nil
+	}
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of statement")
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Value is a column value: nil, int64, float64, string or []byte.
+type Value any
+
+// decodeRecord decodes a record payload into its values.
+func decodeRecord(payload []byte) ([]Value, error) {
+	headerSize, n := readVarint(payload)
+	if n == 0 || headerSize < uint64(n) || headerSize > uint64(len(payload)) {
+		return nil, corrupt("invalid record header")
+	}
+	var types []uint64
+	for pos := n; pos < int(headerSize); {
+		t, k := readVarint(payload[pos:])
+		types = append(types, t)
+		pos += k
+	}
+
+	values := make([]Value, 0, len(types))
+	body := payload[headerSize:]
+	for _, t := range types {
+		if t == 10 || t == 11 {
+			return nil, corrupt("invalid serial type %d", t)
+		}
+		if t >= 12 && (t-12)/2 > uint64(len(body)) {
+			return nil, corrupt("record out of bounds")
+		}
+		size := serialSize(t)
+		if size > len(body) {
+			return nil, corrupt("record out of bounds")
+		}
+		values = append(values, decodeValue(t, body[:size]))
+		body = body[size:]
+	}
+	return values, nil
+}
+
+func serialSize(t uint64) int {
+	switch {
+	case t <= 4:
+		return [...]int{0, 1, 2, 3, 4}[t]
+	case t == 5:
+		return 6
+	case t == 6, t == 7:
+		return 8
+	case t < 12:
+		return 0
+	}
+	return int(t-12) / 2
+}
+
+func decodeValue(t uint64, b []byte) Value {
+	switch {
+	case t == 0:
+		return nil
+	case t <= 6:
+		// big-endian two's complement integers of 1, 2, 3, 4, 6 or 8 bytes
+		v := int64(int8(b[0]))
+		for _, c := range b[1:] {
+			v = v<<8 | int64(c)
+		}
+		return v
+	case t == 7:
+		return math.Float64frombits(binary.BigEndian.Uint64(b))
+	case t == 8:
+		return int64(0)
+	case t == 9:
+		return int64(1)
+	case t >= 12 && t%2 == 0:
+		return append([]byte(nil), b...)
+	case t >= 13:
+		return string(b)
+	}
+	return nil
+}
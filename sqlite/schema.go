@@ -0,0 +1,190 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Table is a table of the schema.
+type Table struct {
+	Name     string
+	RootPage uint32
+	SQL      string
+	Columns  []string
+	// RowidAlias is the index of the INTEGER PRIMARY KEY column, -1 when there is none
+	RowidAlias   int
+	WithoutRowid bool
+}
+
+var masterTable = &Table{
+	Name:       "sqlite_master",
+	RootPage:   1,
+	Columns:    []string{"type", "name", "tbl_name", "rootpage", "sql"},
+	RowidAlias: -1,
+}
+
+// Tables returns the tables of the schema in definition order.
+func (db *DB) Tables() ([]*Table, error) {
+	var tables []*Table
+	err := db.scanTable(1, func(rowid int64, payload []byte) error {
+		values, err := decodeRecord(payload)
+		if err != nil {
+			return err
+		}
+		if len(values) < 5 || values[0] != "table" {
+			return nil
+		}
+		name, _ := values[1].(string)
+		root, _ := values[3].(int64)
+		sql, _ := values[4].(string)
+		table := &Table{Name: name, RootPage: uint32(root), SQL: sql, RowidAlias: -1}
+		table.parseColumns()
+		tables = append(tables, table)
+		return nil
+	})
+	return tables, err
+}
+
+// Table returns the table with the given name, sqlite_master included.
+func (db *DB) Table(name string) (*Table, error) {
+	if strings.EqualFold(name, "sqlite_master") || strings.EqualFold(name, "sqlite_schema") {
+		return masterTable, nil
+	}
+	tables, err := db.Tables()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tables {
+		if strings.EqualFold(t.Name, name) {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("no such table: %s", name)
+}
+
+// parseColumns reads the column names from the CREATE TABLE statement.
+func (t *Table) parseColumns() {
+	start := strings.Index(t.SQL, "(")
+	end := strings.LastIndex(t.SQL, ")")
+	if start == -1 || end <= start {
+		return
+	}
+	t.WithoutRowid = strings.Contains(strings.ToUpper(t.SQL[end:]), "WITHOUT ROWID")
+
+	var types []string
+	var primaryKey []string
+	for _, def := range splitTopLevel(t.SQL[start+1 : end]) {
+		def = strings.TrimSpace(def)
+		if def == "" {
+			continue
+		}
+		if constraint, ok := tableConstraint(def); ok {
+			// Room declares the key as a table constraint: PRIMARY KEY(`id`)
+			if strings.HasPrefix(strings.ToUpper(constraint), "PRIMARY") {
+				primaryKey = columnList(constraint)
+			}
+			continue
+		}
+		name, rest := splitIdentifier(def)
+		rest = strings.ToUpper(rest)
+		if strings.HasPrefix(strings.TrimSpace(rest), "INTEGER") && strings.Contains(rest, "PRIMARY KEY") {
+			t.RowidAlias = len(t.Columns)
+		}
+		t.Columns = append(t.Columns, name)
+		types = append(types, leadingWord(strings.TrimSpace(rest)))
+	}
+
+	// only a single column key declared exactly INTEGER is an alias of the rowid
+	if t.RowidAlias == -1 && len(primaryKey) == 1 {
+		for i, c := range t.Columns {
+			if strings.EqualFold(c, primaryKey[0]) && types[i] == "INTEGER" {
+				t.RowidAlias = i
+			}
+		}
+	}
+}
+
+// tableConstraint returns the constraint without its CONSTRAINT name when def is a table constraint.
+// The keyword has to be a whole word, a column may well be called checked or unique_id.
+func tableConstraint(def string) (string, bool) {
+	if strings.EqualFold(leadingWord(def), "CONSTRAINT") {
+		_, rest := splitIdentifier(strings.TrimSpace(def[len("CONSTRAINT"):]))
+		return strings.TrimSpace(rest), true
+	}
+	switch strings.ToUpper(leadingWord(def)) {
+	case "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+		return def, true
+	}
+	return "", false
+}
+
+// leadingWord returns the unquoted keyword or identifier def starts with.
+func leadingWord(def string) string {
+	end := strings.IndexFunc(def, func(r rune) bool {
+		return !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	if end == -1 {
+		return def
+	}
+	return def[:end]
+}
+
+// columnList returns the column names in the parentheses of a constraint such as PRIMARY KEY(a, b).
+func columnList(constraint string) []string {
+	start := strings.Index(constraint, "(")
+	end := strings.LastIndex(constraint, ")")
+	if start == -1 || end <= start {
+		return nil
+	}
+	var columns []string
+	for _, part := range splitTopLevel(constraint[start+1 : end]) {
+		if part = strings.TrimSpace(part); part != "" {
+			name, _ := splitIdentifier(part)
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+// splitTopLevel splits on commas outside of parentheses and quotes.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// splitIdentifier returns the leading, possibly quoted, identifier and the rest.
+func splitIdentifier(s string) (string, string) {
+	closing := map[byte]byte{'"': '"', '`': '`', '[': ']'}
+	if end, ok := closing[s[0]]; ok {
+		if idx := strings.IndexByte(s[1:], end); idx != -1 {
+			return s[1 : idx+1], s[idx+2:]
+		}
+	}
+	if idx := strings.IndexAny(s, " \t\n"); idx != -1 {
+		return s[:idx], s[idx:]
+	}
+	return s, ""
+}
@@ -0,0 +1,370 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testdata/basic.db has 1024 byte pages, users(id INTEGER PRIMARY KEY, name, score, avatar)
+// with 300 rows where row 150 has a 5000 byte name, and a WITHOUT ROWID table kv.
+// testdata/wal.db holds notes "one" and "two", wal.db-wal renames 1 to "uno" and adds "three".
+// testdata/room.db has the schema shapes Room writes, PRIMARY KEY(`id`) as a table constraint,
+// and todo(id, checked, title, constraint_note) where the second row has a NULL note.
+
+func openTestDB(t *testing.T, name string) *DB {
+	t.Helper()
+	db, err := Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Open(%s): %v", name, err)
+	}
+	return db
+}
+
+func query(t *testing.T, db *DB, sql string) *Result {
+	t.Helper()
+	result, err := db.Query(sql)
+	if err != nil {
+		t.Fatalf("Query(%q): %v", sql, err)
+	}
+	return result
+}
+
+func TestInteriorPages(t *testing.T) {
+	db := openTestDB(t, "basic.db")
+	table, err := db.Table("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := db.page(table.RootPage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root[0] != pageInteriorTable {
+		t.Fatalf("root page type = %d, want an interior page", root[0])
+	}
+
+	result := query(t, db, "SELECT COUNT(*) FROM users")
+	if got := result.Rows[0][0]; got != int64(300) {
+		t.Fatalf("COUNT(*) = %v, want 300", got)
+	}
+	result = query(t, db, "SELECT id FROM users")
+	for i, row := range result.Rows {
+		if row[0] != int64(i+1) {
+			t.Fatalf("row %d has id %v, rows are not in rowid order", i, row[0])
+		}
+	}
+}
+
+func TestOverflowPayload(t *testing.T) {
+	db := openTestDB(t, "basic.db")
+	result := query(t, db, "SELECT name, score FROM users WHERE id = 150 OR id = 151")
+	if len(result.Rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(result.Rows))
+	}
+	if name := result.Rows[0][0]; name != strings.Repeat("x", 5000) {
+		t.Fatalf("overflowed name has %d bytes, want 5000", len(toText(name)))
+	}
+	// the row after the overflowing cell is still decoded
+	if name, score := result.Rows[1][0], result.Rows[1][1]; name != "user151" || score != 75.5 {
+		t.Fatalf("row 151 = %v, %v, want user151, 75.5", name, score)
+	}
+}
+
+func TestRowidAlias(t *testing.T) {
+	db := openTestDB(t, "basic.db")
+	result := query(t, db, "SELECT id, rowid, name, avatar FROM users WHERE id = 42")
+	if len(result.Rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(result.Rows))
+	}
+	row := result.Rows[0]
+	// the INTEGER PRIMARY KEY column is stored as NULL in the record
+	if row[0] != int64(42) || row[1] != int64(42) {
+		t.Fatalf("id, rowid = %v, %v, want 42, 42", row[0], row[1])
+	}
+	if row[2] != "user42" {
+		t.Fatalf("name = %v, want user42", row[2])
+	}
+	if avatar, ok := row[3].([]byte); !ok || len(avatar) != 1 || avatar[0] != 42 {
+		t.Fatalf("avatar = %v, want [42]", row[3])
+	}
+}
+
+func TestWithoutRowidRejected(t *testing.T) {
+	db := openTestDB(t, "basic.db")
+	_, err := db.Query("SELECT * FROM kv")
+	if err == nil || !strings.Contains(err.Error(), "WITHOUT ROWID") {
+		t.Fatalf("Query on a WITHOUT ROWID table returned %v", err)
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		sql        string
+		columns    string
+		rowidAlias int
+	}{
+		{"CREATE TABLE todo (id INTEGER PRIMARY KEY, checked INTEGER, title TEXT)", "id,checked,title", 0},
+		{"CREATE TABLE t (unique_id TEXT, primary_color TEXT, foreign_key INT, constraint_name TEXT)",
+			"unique_id,primary_color,foreign_key,constraint_name", -1},
+		{"CREATE TABLE t (\"unique\" TEXT, check_ok INT, CHECK (check_ok > 0), UNIQUE(check_ok))", "unique,check_ok", -1},
+		{"CREATE TABLE `User` (`id` INTEGER NOT NULL, `name` TEXT, PRIMARY KEY(`id`))", "id,name", 0},
+		{"CREATE TABLE t (name TEXT, id integer NOT NULL, CONSTRAINT pk PRIMARY KEY (id ASC))", "name,id", 1},
+		{"CREATE TABLE t (id INT NOT NULL, PRIMARY KEY(id))", "id", -1},
+		{"CREATE TABLE t (id INTEGER, x TEXT, PRIMARY KEY(id, x))", "id,x", -1},
+		{"CREATE TABLE t (a TEXT, b TEXT, FOREIGN KEY(a) REFERENCES p(id))", "a,b", -1},
+	}
+	for _, tt := range tests {
+		table := &Table{SQL: tt.sql, RowidAlias: -1}
+		table.parseColumns()
+		if got := strings.Join(table.Columns, ","); got != tt.columns {
+			t.Errorf("%s: columns = %s, want %s", tt.sql, got, tt.columns)
+		}
+		if table.RowidAlias != tt.rowidAlias {
+			t.Errorf("%s: rowid alias = %d, want %d", tt.sql, table.RowidAlias, tt.rowidAlias)
+		}
+	}
+}
+
+func TestRoomSchema(t *testing.T) {
+	db := openTestDB(t, "room.db")
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		// the ids are stored as NULL in the records, they must come from the rowid
+		{"SELECT id, name, unique_id FROM User", "1,a,u-1;2,b,u-2"},
+		{"SELECT * FROM `User` WHERE id = 2", "2,b,u-2"},
+		{"SELECT checked, title, constraint_note FROM todo ORDER BY id", "1,buy milk,n1;0,walk,NULL"},
+		{"SELECT name, count FROM Tag", "x,3"},
+		{"SELECT identity_hash FROM room_master_table WHERE id = 42", "8d7c4f1e2b"},
+	}
+	for _, tt := range tests {
+		if got := formatRows(query(t, db, tt.sql)); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	basic, room := openTestDB(t, "basic.db"), openTestDB(t, "room.db")
+	tests := []struct {
+		db   *DB
+		sql  string
+		want string
+	}{
+		{basic, "SELECT id FROM users WHERE id BETWEEN 10 AND 12", "10;11;12"},
+		{basic, "SELECT id FROM users WHERE id NOT BETWEEN 2 AND 299", "1;300"},
+		{basic, "SELECT id FROM users WHERE id IN (3, 5, 400)", "3;5"},
+		{basic, "SELECT id FROM users WHERE id NOT IN (1, 3) AND id < 5", "2;4"},
+		{basic, "SELECT name FROM users WHERE name LIKE 'user2_'", "user20;user21;user22;user23;user24;user25;user26;user27;user28;user29"},
+		{basic, "SELECT COUNT(*) FROM users WHERE name LIKE 'USER29%'", "11"},
+		{basic, "SELECT id FROM users WHERE name NOT LIKE 'user%'", "150"},
+		{basic, "SELECT id FROM users ORDER BY id DESC LIMIT 3", "300;299;298"},
+		{basic, "SELECT id FROM users LIMIT 2 OFFSET 5", "6;7"},
+		{basic, "SELECT id FROM users ORDER BY id DESC LIMIT 2 OFFSET 1", "299;298"},
+		{basic, "SELECT id, score FROM users WHERE id < 4 ORDER BY 2 DESC", "3,1.5;2,1;1,0.5"},
+		{basic, "SELECT id AS n FROM users WHERE id < 4 ORDER BY n DESC", "3;2;1"},
+		{basic, "SELECT score > 100 AS high FROM users ORDER BY high", strings.Repeat("0;", 200) + strings.Repeat("1;", 99) + "1"},
+		{basic, "SELECT DISTINCT score > 100 FROM users", "0;1"},
+		{basic, "SELECT count(*) AS n FROM users WHERE id > 100", "200"},
+		{basic, `SELECT "id", [name], ` + "`score`" + ` FROM "users" WHERE "id" = 1`, "1,user1,0.5"},
+		{basic, "SELECT rowid, _rowid_, oid FROM users WHERE rowid > 298", "299,299,299;300,300,300"},
+		{basic, "SELECT id FROM users WHERE id = 2 OR id = 1 AND name = 'nobody'", "2"},
+		{basic, "SELECT id FROM users WHERE NOT (id > 1)", "1"},
+		{basic, "SELECT id FROM users WHERE -id >= -1", "1"},
+		{room, "SELECT id FROM todo WHERE constraint_note IS NULL", "2"},
+		{room, "SELECT id FROM todo WHERE constraint_note IS NOT NULL", "1"},
+		// NULL is unknown: NULL OR true is true, NULL AND true and NOT NULL are NULL
+		{room, "SELECT id FROM todo WHERE constraint_note = 'n1' OR id = 2", "1;2"},
+		{room, "SELECT id FROM todo WHERE constraint_note != 'n1' AND id = 2", ""},
+		{room, "SELECT id FROM todo WHERE NOT (constraint_note = 'n1')", ""},
+		{room, "SELECT constraint_note = 'x' OR 1, constraint_note = 'x' AND 1, constraint_note = 'x' AND 0 FROM todo WHERE id = 2", "1,NULL,0"},
+		{room, "SELECT id FROM todo WHERE constraint_note IN ('n1', NULL)", "1"},
+		{room, "SELECT constraint_note FROM todo ORDER BY constraint_note", "NULL;n1"},
+	}
+	for _, tt := range tests {
+		if got := formatRows(query(t, tt.db, tt.sql)); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	db := openTestDB(t, "basic.db")
+	for _, sql := range []string{
+		"SELECT nope FROM users",
+		"SELECT * FROM nope",
+		"SELECT id, COUNT(*) FROM users",
+		"DELETE FROM users",
+		"SELECT id FROM users WHERE",
+		"SELECT 'unterminated FROM users",
+	} {
+		if _, err := db.Query(sql); err == nil {
+			t.Errorf("Query(%q) returned no error", sql)
+		}
+	}
+}
+
+func TestWALReplay(t *testing.T) {
+	db := openTestDB(t, "wal.db")
+	result := query(t, db, "SELECT body FROM notes ORDER BY id")
+	var bodies []string
+	for _, row := range result.Rows {
+		bodies = append(bodies, toText(row[0]))
+	}
+	if got := strings.Join(bodies, ","); got != "uno,two,three" {
+		t.Fatalf("notes = %s, want uno,two,three", got)
+	}
+}
+
+func TestWALUncommittedFramesIgnored(t *testing.T) {
+	dir := t.TempDir()
+	data, wal := readFixture(t, "wal.db"), readFixture(t, "wal.db-wal")
+	// clearing the commit size of the only frame leaves the transaction uncommitted
+	binary.BigEndian.PutUint32(wal[walHeaderSize+4:], 0)
+	db := openBytes(t, dir, data, wal)
+
+	result, err := db.Query("SELECT body FROM notes ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rows) != 2 || result.Rows[0][0] != "one" {
+		t.Fatalf("uncommitted wal frames were applied: %v", result.Rows)
+	}
+}
+
+func TestMalformedPages(t *testing.T) {
+	base := readFixture(t, "basic.db")
+	db := openTestDB(t, "basic.db")
+	table, err := db.Table("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := int(table.RootPage-1) * db.pageSize
+	firstCell := int(binary.BigEndian.Uint16(base[root+12:]))
+
+	tests := []struct {
+		name   string
+		mutate func(data []byte)
+	}{
+		{"page size", func(data []byte) { binary.BigEndian.PutUint16(data[16:], 0) }},
+		{"reserved bytes", func(data []byte) { data[20] = 255 }},
+		{"cell count", func(data []byte) { binary.BigEndian.PutUint16(data[root+3:], 0xffff) }},
+		{"cell pointer", func(data []byte) { binary.BigEndian.PutUint16(data[root+12:], 0xfff0) }},
+		{"cell pointer into header", func(data []byte) { binary.BigEndian.PutUint16(data[root+12:], 2) }},
+		{"child cycle", func(data []byte) { binary.BigEndian.PutUint32(data[root+firstCell:], table.RootPage) }},
+		{"child out of range", func(data []byte) { binary.BigEndian.PutUint32(data[root+firstCell:], 0xffffff) }},
+		{"right child", func(data []byte) { binary.BigEndian.PutUint32(data[root+8:], 0) }},
+		{"page type", func(data []byte) { data[root] = 0x42 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append([]byte(nil), base...)
+			tt.mutate(data)
+			db := openBytes(t, t.TempDir(), data, nil)
+			if db == nil {
+				return
+			}
+			if _, err := db.Query("SELECT * FROM users"); err == nil {
+				t.Fatal("malformed database was read without an error")
+			}
+		})
+	}
+}
+
+func TestMalformedRecords(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":            {},
+		"header too large": {0x7f, 0x01},
+		"header too small": {0x00, 0x01},
+		"body too short":   {0x02, 0x06, 0x01},
+		"reserved type":    {0x02, 0x0a},
+		"huge text":        {0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+	for name, payload := range tests {
+		if _, err := decodeRecord(payload); err == nil {
+			t.Errorf("%s: decodeRecord(%x) returned no error", name, payload)
+		}
+	}
+}
+
+// TestCorruption flips random bytes of the fixtures, reading them may fail but must not panic.
+func TestCorruption(t *testing.T) {
+	dir := t.TempDir()
+	fixtures := map[string][]byte{"basic.db": readFixture(t, "basic.db"), "wal.db": readFixture(t, "wal.db")}
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		name := []string{"basic.db", "wal.db"}[i%2]
+		data := append([]byte(nil), fixtures[name]...)
+		for n := 1 + r.Intn(8); n > 0; n-- {
+			// keep the magic string so the corruption reaches the b-tree
+			data[16+r.Intn(len(data)-16)] = byte(r.Intn(256))
+		}
+
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					t.Fatalf("iteration %d on %s panicked: %v", i, name, p)
+				}
+			}()
+			db := openBytes(t, dir, data, nil)
+			if db == nil {
+				return
+			}
+			tables, err := db.Tables()
+			if err != nil {
+				return
+			}
+			for _, table := range append(tables, masterTable) {
+				db.Query("SELECT * FROM " + table.Name)
+			}
+		}()
+	}
+}
+
+// formatRows renders rows as "a,b;c,d" with FormatValue.
+func formatRows(result *Result) string {
+	rows := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		values := make([]string, 0, len(row))
+		for _, v := range row {
+			values = append(values, FormatValue(v))
+		}
+		rows = append(rows, strings.Join(values, ","))
+	}
+	return strings.Join(rows, ";")
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// openBytes writes data, and wal when it is not nil, to dir and opens it, nil when Open fails.
+func openBytes(t *testing.T, dir string, data, wal []byte) *DB {
+	t.Helper()
+	path := filepath.Join(dir, "test.db")
+	os.Remove(path + "-wal")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if wal != nil {
+		if err := os.WriteFile(path+"-wal", wal, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := Open(path)
+	if err != nil {
+		return nil
+	}
+	return db
+}
@@ -0,0 +1,149 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"strings"
+)
+
+// AppInfoStrategy prints the parsed `dumpsys package` entry of an app
+type AppInfoStrategy struct {
+	PackageName string
+	JSON        bool
+	Certs       bool
+}
+
+type appInfo struct {
+	*adb.PackageInfo
+	Certificates []string               `json:"certificates,omitempty"`
+	Components   *adb.PackageComponents `json:"components"`
+}
+
+func (s *AppInfoStrategy) Run() error {
+	info, components, err := adb.GetPackageDetail(s.PackageName)
+	if err != nil {
+		return err
+	}
+	detail := &appInfo{PackageInfo: info, Components: components}
+
+	if s.Certs {
+		if detail.Certificates, err = pullCertificateDigests(s.PackageName); err != nil {
+			return err
+		}
+	}
+
+	if s.JSON {
+		return logJSON(detail)
+	}
+	util.Log(formatAppInfo(detail))
+	return nil
+}
+
+// pullCertificateDigests pulls base.apk and reads the certificates from its signing block
+func pullCertificateDigests(packageName string) ([]string, error) {
+	paths, err := adb.GetPackagePaths(packageName)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("cannot find apk path")
+	}
+
+	dir, err := os.MkdirTemp("", "rabbit-info-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	local := filepath.Join(dir, "base.apk")
+	if err := pullFile(paths[0], local); err != nil {
+		return nil, err
+	}
+	return adb.CertificateDigests(local)
+}
+
+func formatAppInfo(d *appInfo) string {
+	var sb strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&sb, "%-18s%s\n", name, value)
+		}
+	}
+
+	field("package", d.Name)
+	field("version", fmt.Sprintf("%s (%s)", orDash(d.VersionName), orDash(d.VersionCode)))
+	field("sdk", fmt.Sprintf("min %s, target %s", orDash(d.MinSDK), orDash(d.TargetSDK)))
+	field("uid", d.UID)
+	field("code path", d.CodePath)
+	field("data dir", d.DataDir)
+	field("abi", d.PrimaryCPUAbi)
+	field("debuggable", fmt.Sprint(d.HasFlag("DEBUGGABLE")))
+	field("allow backup", fmt.Sprint(d.HasFlag("ALLOW_BACKUP")))
+	field("test only", fmt.Sprint(d.HasFlag("TEST_ONLY")))
+	field("flags", strings.Join(d.Flags, " "))
+	field("private flags", strings.Join(d.PrivateFlags, " "))
+	field("installer", d.Installer)
+	field("initiated by", d.InstallInitiator)
+	field("originated from", d.InstallOriginator)
+	field("first install", d.FirstInstallTime)
+	field("last update", d.LastUpdateTime)
+	if len(d.Signatures) > 0 {
+		field("signatures", fmt.Sprintf("v%s %s", d.SigningVersion, strings.Join(d.Signatures, ", ")))
+	}
+	for _, cert := range d.Certificates {
+		field("cert sha-256", cert)
+	}
+
+	for _, u := range d.Users {
+		fmt.Fprintf(&sb, "\nuser %s: installed=%t hidden=%t suspended=%t stopped=%t enabled=%s\n",
+			u.ID, u.Installed, u.Hidden, u.Suspended, u.Stopped, orDash(u.Enabled))
+	}
+
+	if len(d.DeclaredPermissions) > 0 {
+		sb.WriteString("\ndeclared permissions:\n")
+		for _, p := range d.DeclaredPermissions {
+			fmt.Fprintf(&sb, "  %s (%s)\n", p.Name, orDash(p.Protection))
+		}
+	}
+	if len(d.RequestedPermissions) > 0 {
+		sb.WriteString("\nrequested permissions:\n")
+		states := d.PermissionStates("")
+		for _, name := range d.RequestedPermissions {
+			fmt.Fprintf(&sb, "  %s %s\n", grantMark(states, name), name)
+		}
+	}
+
+	components := []struct {
+		name string
+		list []string
+	}{
+		{"activities", d.Components.Activities},
+		{"services", d.Components.Services},
+		{"receivers", d.Components.Receivers},
+		{"providers", d.Components.Providers},
+	}
+	for _, c := range components {
+		if len(c.list) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n%s:\n", c.name)
+		for _, component := range c.list {
+			fmt.Fprintf(&sb, "  %s\n", component)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func grantMark(states map[string]adb.PermissionState, name string) string {
+	state, ok := states[name]
+	switch {
+	case !ok:
+		return "[ ]"
+	case state.Granted:
+		return "[x]"
+	}
+	return "[-]"
+}
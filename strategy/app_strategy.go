@@ -1,7 +1,12 @@
 package strategy
 
 import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"rabbit-go/adb"
@@ -61,7 +66,7 @@ func (s *KillStrategy) GetPackageName() string {
 	return s.PackageName
 }
 
-// GrantStrategy grants all permissions
+// GrantStrategy grants all requested runtime permissions
 type GrantStrategy struct {
 	PackageName string
 }
@@ -75,47 +80,15 @@ func (s *GrantStrategy) CanHandle() bool {
 }
 
 func (s *GrantStrategy) Run(packageName string) error {
-	cmd := fmt.Sprintf("adb shell dumpsys package %s", packageName)
-	output, err := adb.Exec(cmd, false, nil)
-	if err != nil {
-		return err
-	}
-
-	permissions := getRequestedPermissions(util.MultiLine(output))
-	for _, perm := range permissions {
-		grantCmd := fmt.Sprintf("adb shell pm grant %s %s", packageName, perm)
-		_, _ = adb.Exec(grantCmd, true, func(errorMsg string) bool {
-			return strings.Contains(errorMsg, "Neither user 2000 nor current process has android.permission.GRANT_RUNTIME_PERMISSIONS")
-		})
-	}
-	return nil
+	change := &PermChangeStrategy{PackageName: packageName, Grant: true, Dangerous: true}
+	return change.Run()
 }
 
 func (s *GrantStrategy) GetPackageName() string {
 	return s.PackageName
 }
 
-func getRequestedPermissions(lines []string) []string {
-	var permissions []string
-	inPermissionSection := false
-
-	for _, line := range lines {
-		if !strings.Contains(line, ".permission.") {
-			inPermissionSection = false
-		}
-		if strings.Contains(line, "requested permissions:") {
-			inPermissionSection = true
-			continue
-		}
-		if inPermissionSection {
-			permissionName := strings.TrimSpace(strings.ReplaceAll(line, ":", ""))
-			permissions = append(permissions, permissionName)
-		}
-	}
-	return permissions
-}
-
-// RevokeStrategy revokes all permissions
+// RevokeStrategy revokes all granted runtime permissions
 type RevokeStrategy struct {
 	PackageName string
 }
@@ -129,24 +102,8 @@ func (s *RevokeStrategy) CanHandle() bool {
 }
 
 func (s *RevokeStrategy) Run(packageName string) error {
-	cmd := fmt.Sprintf("adb shell dumpsys package %s", packageName)
-	output, err := adb.Exec(cmd, false, nil)
-	if err != nil {
-		return err
-	}
-
-	lines := util.MultiLine(output)
-	for _, line := range lines {
-		if strings.Contains(line, "permission") && strings.Contains(line, "granted=true") {
-			parts := strings.Split(line, ":")
-			if len(parts) > 0 {
-				permission := strings.TrimSpace(parts[0])
-				revokeCmd := fmt.Sprintf("adb shell pm revoke %s %s", packageName, permission)
-				_, _ = adb.Exec(revokeCmd, true, nil)
-			}
-		}
-	}
-	return nil
+	change := &PermChangeStrategy{PackageName: packageName, Dangerous: true}
+	return change.Run()
 }
 
 func (s *RevokeStrategy) GetPackageName() string {
@@ -226,19 +183,37 @@ func (s *StartAppDetailStrategy) GetPackageName() string {
 	return s.PackageName
 }
 
-// ExportAppStrategy exports an app
+// ExportAppStrategy exports the base and split apks of an app
 type ExportAppStrategy struct {
 	PackageName string
+	Dir         string
+	AsApks      bool
 }
 
-func NewExportAppStrategy(packageName string) *ExportAppStrategy {
-	return &ExportAppStrategy{PackageName: packageName}
+func NewExportAppStrategy(packageName, dir string, asApks bool) *ExportAppStrategy {
+	return &ExportAppStrategy{PackageName: packageName, Dir: dir, AsApks: asApks}
 }
 
 func (s *ExportAppStrategy) CanHandle() bool {
 	return s.PackageName != ""
 }
 
+// ExportManifest lists the exported apks with their SHA-256 checksums
+type ExportManifest struct {
+	Package     string         `json:"package"`
+	VersionName string         `json:"versionName"`
+	VersionCode string         `json:"versionCode"`
+	Archive     *ExportedFile  `json:"archive,omitempty"`
+	Files       []ExportedFile `json:"files"`
+}
+
+type ExportedFile struct {
+	Name       string `json:"name"`
+	DevicePath string `json:"devicePath,omitempty"`
+	Size       int64  `json:"size"`
+	SHA256     string `json:"sha256"`
+}
+
 func (s *ExportAppStrategy) Run(packageName string) error {
 	// Check if package exists
 	cmd := fmt.Sprintf("adb shell pm list packages %s", packageName)
@@ -250,7 +225,7 @@ func (s *ExportAppStrategy) Run(packageName string) error {
 	lines := util.MultiLine(output)
 	packageExists := false
 	for _, line := range lines {
-		if strings.Contains(line, packageName) {
+		if strings.TrimSpace(line) == "package:"+packageName {
 			packageExists = true
 			break
 		}
@@ -261,21 +236,36 @@ func (s *ExportAppStrategy) Run(packageName string) error {
 		return nil
 	}
 
-	// Get APK path
-	pathCmd := fmt.Sprintf("adb shell pm path %s", packageName)
-	apkPath, err := adb.Exec(pathCmd, false, nil)
+	// Get all APK paths, split apks included
+	apkPaths, err := adb.GetPackagePaths(packageName)
 	if err != nil {
 		return err
 	}
-
-	apkPath = strings.TrimPrefix(strings.TrimSpace(apkPath), "package:")
-	if apkPath == "" {
+	if len(apkPaths) == 0 {
 		return fmt.Errorf("cannot find apk path")
 	}
 
-	// Pull APK
-	destFile := fmt.Sprintf("./%s.apk", packageName)
-	absPath, err := filepath.Abs(destFile)
+	versionName, versionCode, err := adb.GetPackageVersion(packageName)
+	if err != nil {
+		return err
+	}
+	name := packageName
+	if versionName != "" || versionCode != "" {
+		name = fmt.Sprintf("%s-%s-%s", packageName, safeFileName(versionName), safeFileName(versionCode))
+	}
+
+	dir := s.Dir
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	dest := filepath.Join(dir, name)
+	if s.AsApks {
+		dest += ".apks"
+	}
+	absPath, err := filepath.Abs(dest)
 	if err != nil {
 		return err
 	}
@@ -285,17 +275,119 @@ func (s *ExportAppStrategy) Run(packageName string) error {
 		return nil
 	}
 
-	pullCmd := fmt.Sprintf("adb pull %s %s", apkPath, absPath)
-	output, err = adb.Exec(pullCmd, false, nil)
+	pullDir := absPath
+	if s.AsApks {
+		if pullDir, err = os.MkdirTemp("", "rabbit-export-"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(pullDir)
+	} else if err := os.MkdirAll(pullDir, 0755); err != nil {
+		return err
+	}
+
+	t, err := newTransfer(false)
 	if err != nil {
 		return err
 	}
+	defer t.close()
+
+	manifest := &ExportManifest{Package: packageName, VersionName: versionName, VersionCode: versionCode}
+	for _, apkPath := range apkPaths {
+		local := filepath.Join(pullDir, filepath.Base(apkPath))
+		if err := t.pull(apkPath, local); err != nil {
+			return err
+		}
+
+		file, err := checksumFile(local)
+		if err != nil {
+			return err
+		}
+		file.DevicePath = apkPath
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	manifestPath := filepath.Join(absPath, "manifest.json")
+	if s.AsApks {
+		if err := writeApksArchive(absPath, pullDir, manifest); err != nil {
+			return err
+		}
+		archive, err := checksumFile(absPath)
+		if err != nil {
+			return err
+		}
+		manifest.Archive = &archive
+		manifestPath = strings.TrimSuffix(absPath, ".apks") + ".json"
+	}
+	if err := writeExportManifest(manifestPath, manifest); err != nil {
+		return err
+	}
 
-	util.Log(output)
-	util.Log(fmt.Sprintf("apk has been saved in %s", absPath))
+	util.Log(fmt.Sprintf("%d apk(s) have been saved in %s", len(manifest.Files), absPath))
+	util.Log(fmt.Sprintf("manifest has been saved in %s", manifestPath))
 	return nil
 }
 
+func checksumFile(path string) (ExportedFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ExportedFile{}, err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return ExportedFile{}, err
+	}
+	return ExportedFile{Name: filepath.Base(path), Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+func writeExportManifest(path string, manifest *ExportManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// writeApksArchive zips the pulled apks into an .apks archive that `rabbit-go install` accepts
+func writeApksArchive(path, dir string, manifest *ExportManifest) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := zip.NewWriter(out)
+	for _, f := range manifest.Files {
+		entry, err := w.Create(f.Name)
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(filepath.Join(dir, f.Name))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(entry, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
 func (s *ExportAppStrategy) GetPackageName() string {
 	return s.PackageName
 }
+
+// safeFileName replaces path separators, characters reserved on Windows and control characters,
+// a versionName such as "1.0/beta" would otherwise create directories
+func safeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,131 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type appOpChange struct {
+	op   string
+	mode string
+}
+
+// AppOpPresets bundles the op changes we make most often
+var AppOpPresets = map[string][]appOpChange{
+	"allow-overlay":       {{"SYSTEM_ALERT_WINDOW", "allow"}},
+	"deny-overlay":        {{"SYSTEM_ALERT_WINDOW", "ignore"}},
+	"restrict-background": {{"RUN_IN_BACKGROUND", "ignore"}, {"RUN_ANY_IN_BACKGROUND", "ignore"}},
+	"allow-background":    {{"RUN_IN_BACKGROUND", "allow"}, {"RUN_ANY_IN_BACKGROUND", "allow"}},
+	"allow-exact-alarm":   {{"SCHEDULE_EXACT_ALARM", "allow"}},
+	"deny-exact-alarm":    {{"SCHEDULE_EXACT_ALARM", "ignore"}},
+	"allow-clipboard":     {{"READ_CLIPBOARD", "allow"}},
+	"deny-clipboard":      {{"READ_CLIPBOARD", "ignore"}},
+	"allow-install":       {{"REQUEST_INSTALL_PACKAGES", "allow"}},
+	"allow-usage-stats":   {{"GET_USAGE_STATS", "allow"}},
+	"allow-all-files":     {{"MANAGE_EXTERNAL_STORAGE", "allow"}},
+}
+
+// AppOpPresetNames returns the sorted preset names
+func AppOpPresetNames() []string {
+	names := make([]string, 0, len(AppOpPresets))
+	for name := range AppOpPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AppOpsGetStrategy prints the app ops of a package with their last access
+type AppOpsGetStrategy struct {
+	PackageName string
+	Op          string
+	JSON        bool
+}
+
+func (s *AppOpsGetStrategy) Run() error {
+	op := s.Op
+	if op != "" {
+		op = adb.AppOpName(op)
+	}
+	ops, err := adb.GetAppOps(s.PackageName, op)
+	if err != nil {
+		return err
+	}
+
+	if s.JSON {
+		return logJSON(ops)
+	}
+	if len(ops) == 0 {
+		util.Log("no app ops")
+		return nil
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OP\tMODE\tUID MODE\tLAST ACCESS\tLAST REJECT")
+	for _, op := range ops {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", op.Name, orDash(op.Mode), orDash(op.UIDMode), orDash(op.LastAccess), orDash(op.LastReject))
+	}
+	w.Flush()
+	util.Log(strings.TrimRight(sb.String(), "\n"))
+	return nil
+}
+
+// AppOpsSetStrategy sets the mode of an op, or applies a preset when Mode is empty
+type AppOpsSetStrategy struct {
+	PackageName string
+	Op          string
+	Mode        string
+	UID         bool
+}
+
+func (s *AppOpsSetStrategy) Run() error {
+	var changes []appOpChange
+	if s.Mode == "" {
+		preset, ok := AppOpPresets[s.Op]
+		if !ok {
+			return fmt.Errorf("unknown preset %s, available: %s", s.Op, strings.Join(AppOpPresetNames(), ", "))
+		}
+		changes = preset
+	} else {
+		if !slices.Contains(adb.AppOpModes, s.Mode) {
+			return fmt.Errorf("invalid mode %s, available: %s", s.Mode, strings.Join(adb.AppOpModes, ", "))
+		}
+		changes = []appOpChange{{adb.AppOpName(s.Op), s.Mode}}
+	}
+
+	for _, c := range changes {
+		if err := adb.SetAppOp(s.PackageName, c.op, c.mode, s.UID); err != nil {
+			return fmt.Errorf("set %s to %s: %w", c.op, c.mode, err)
+		}
+		util.Log(fmt.Sprintf("%s: %s", c.op, c.mode))
+	}
+	return nil
+}
+
+// AppOpsResetStrategy resets a single op or every op of a package to the default mode
+type AppOpsResetStrategy struct {
+	PackageName string
+	Op          string
+}
+
+func (s *AppOpsResetStrategy) Run() error {
+	if s.Op != "" {
+		op := adb.AppOpName(s.Op)
+		if err := adb.SetAppOp(s.PackageName, op, "default", false); err != nil {
+			return err
+		}
+		util.Log(fmt.Sprintf("%s: default", op))
+		return nil
+	}
+	if err := adb.ResetAppOps(s.PackageName); err != nil {
+		return err
+	}
+	util.Log(fmt.Sprintf("all app ops of %s have been reset", s.PackageName))
+	return nil
+}
@@ -0,0 +1,136 @@
+package strategy
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"strings"
+	"time"
+)
+
+// app data directories saved by a backup
+var backupDirs = []string{"databases", "shared_prefs", "files", "no_backup"}
+
+// AppBackupStrategy saves the data directory of a debuggable app as a tar archive
+type AppBackupStrategy struct {
+	PackageName string
+	Dir         string
+}
+
+func (s *AppBackupStrategy) Run() error {
+	if err := adb.CheckRunAs(s.PackageName); err != nil {
+		return err
+	}
+
+	listing, err := adb.RunAs(s.PackageName, "ls")
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for _, name := range strings.Fields(listing) {
+		existing[name] = true
+	}
+	var dirs []string
+	for _, dir := range backupDirs {
+		if existing[dir] {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("%s has no data to back up", s.PackageName)
+	}
+
+	// stop the app so databases are not written while they are copied
+	if _, err := adb.Exec(fmt.Sprintf("adb shell am force-stop %s", s.PackageName), false, nil); err != nil {
+		return err
+	}
+
+	dir := s.Dir
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	timestamp := time.Now().Format("2006_01_02_15_04_05")
+	archive, err := filepath.Abs(filepath.Join(dir, fmt.Sprintf("%s_%s.tar", s.PackageName, timestamp)))
+	if err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf("adb exec-out run-as %s tar -cf - %s > %s", s.PackageName, strings.Join(dirs, " "), util.ShellQuote(archive))
+	if _, err := adb.Exec(cmd, false, nil); err != nil {
+		return err
+	}
+	info, err := os.Stat(archive)
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		os.Remove(archive)
+		return fmt.Errorf("backup of %s is empty", s.PackageName)
+	}
+	// run-as and tar print their errors to stdout, which exec-out writes into the archive
+	if err := checkTarArchive(archive); err != nil {
+		os.Remove(archive)
+		return fmt.Errorf("backup of %s failed: %v", s.PackageName, err)
+	}
+
+	util.Log(fmt.Sprintf("backed up %s (%d bytes)", strings.Join(dirs, ", "), info.Size()))
+	util.Log(fmt.Sprintf("backup has been saved in %s", archive))
+	return nil
+}
+
+// AppRestoreStrategy replaces the data directory of a debuggable app with a backup archive
+type AppRestoreStrategy struct {
+	PackageName string
+	Archive     string
+}
+
+func (s *AppRestoreStrategy) Run() error {
+	// the data directory is removed before extracting, so never restore something that is not a tar
+	if err := checkTarArchive(s.Archive); err != nil {
+		return err
+	}
+	if err := adb.CheckRunAs(s.PackageName); err != nil {
+		return err
+	}
+
+	if _, err := adb.Exec(fmt.Sprintf("adb shell am force-stop %s", s.PackageName), false, nil); err != nil {
+		return err
+	}
+
+	// the archive is streamed into tar running as the app user, so restored files get the app uid and selinux label
+	script := fmt.Sprintf("rm -rf %s && tar -xf -", strings.Join(backupDirs, " "))
+	cmd := fmt.Sprintf("adb exec-in run-as %s sh -c %s < %s", s.PackageName, adb.QuoteRemote(script), util.ShellQuote(s.Archive))
+	output, err := adb.Exec(cmd, true, nil)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(output) != "" {
+		return fmt.Errorf("restore failed: %s", strings.TrimSpace(output))
+	}
+
+	util.Log(fmt.Sprintf("%s has been restored from %s", s.PackageName, s.Archive))
+	return nil
+}
+
+// checkTarArchive checks the ustar magic of the first header, the error holds the start of the
+// file since a failed backup contains the message of run-as or tar instead
+func checkTarArchive(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	header := make([]byte, 512)
+	n, _ := io.ReadFull(f, header)
+	if n < 263 || string(header[257:262]) != "ustar" {
+		return fmt.Errorf("%s is not a tar archive: %s", path, strings.TrimSpace(strings.TrimRight(string(header[:min(n, 200)]), "\x00")))
+	}
+	return nil
+}
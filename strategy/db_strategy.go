@@ -0,0 +1,152 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"rabbit-go/adb"
+	"rabbit-go/sqlite"
+	"rabbit-go/util"
+	"strings"
+	"text/tabwriter"
+)
+
+// DBListStrategy lists the databases of a debuggable app
+type DBListStrategy struct {
+	PackageName string
+	JSON        bool
+}
+
+func (s *DBListStrategy) Run() error {
+	if err := checkDebuggable(s.PackageName); err != nil {
+		return err
+	}
+	databases, err := adb.ListDatabases(s.PackageName)
+	if err != nil {
+		return err
+	}
+	if s.JSON {
+		return logJSON(databases)
+	}
+	if len(databases) == 0 {
+		util.Log(fmt.Sprintf("%s has no databases", s.PackageName))
+		return nil
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tWAL\tJOURNAL")
+	for _, db := range databases {
+		wal, journal := "-", "-"
+		if n, ok := db.Related["-wal"]; ok {
+			wal = formatSize(n)
+		}
+		if _, ok := db.Related["-journal"]; ok {
+			journal = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", db.Name, formatSize(db.Size), wal, journal)
+	}
+	w.Flush()
+	util.Log(strings.TrimRight(sb.String(), "\n"))
+	return nil
+}
+
+// DBPullStrategy copies a database with its -wal and -shm files
+type DBPullStrategy struct {
+	PackageName string
+	Name        string
+	Dir         string
+	Stop        bool
+}
+
+func (s *DBPullStrategy) Run() error {
+	if err := s.prepare(); err != nil {
+		return err
+	}
+	dir := s.Dir
+	if dir == "" {
+		dir = "."
+	}
+	path, err := adb.PullDatabase(s.PackageName, s.Name, dir)
+	if err != nil {
+		return err
+	}
+	util.Log(fmt.Sprintf("%s has been saved in %s", s.Name, path))
+	return nil
+}
+
+// prepare force-stops the app with Stop so no transaction is written while the files are copied
+func (s *DBPullStrategy) prepare() error {
+	if err := checkDebuggable(s.PackageName); err != nil {
+		return err
+	}
+	if s.Stop {
+		_, err := adb.Exec(fmt.Sprintf("adb shell am force-stop %s", s.PackageName), false, nil)
+		return err
+	}
+	return nil
+}
+
+// DBQueryStrategy pulls a temporary copy of a database and runs a SELECT on it locally
+type DBQueryStrategy struct {
+	PackageName string
+	Name        string
+	SQL         string
+	JSON        bool
+	Stop        bool
+}
+
+func (s *DBQueryStrategy) Run() error {
+	dir, err := os.MkdirTemp("", "rabbit-db-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	pull := &DBPullStrategy{PackageName: s.PackageName, Name: s.Name, Stop: s.Stop}
+	if err := pull.prepare(); err != nil {
+		return err
+	}
+	path, err := adb.PullDatabase(s.PackageName, s.Name, dir)
+	if err != nil {
+		return err
+	}
+	return queryDatabase(path, s.SQL, s.JSON)
+}
+
+func queryDatabase(path, sql string, asJSON bool) error {
+	db, err := sqlite.Open(path)
+	if err != nil {
+		return err
+	}
+	result, err := db.Query(sql)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	if asJSON {
+		rows := make([]map[string]sqlite.Value, 0, len(result.Rows))
+		for _, row := range result.Rows {
+			m := make(map[string]sqlite.Value, len(row))
+			for i, v := range row {
+				m[result.Columns[i]] = v
+			}
+			rows = append(rows, m)
+		}
+		return logJSON(rows)
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(result.Columns, "\t"))
+	for _, row := range result.Rows {
+		values := make([]string, len(row))
+		for i, v := range row {
+			values[i] = strings.ReplaceAll(sqlite.FormatValue(v), "\n", "\\n")
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	w.Flush()
+	util.Log(fmt.Sprintf("%s%d row(s)", sb.String(), len(result.Rows)))
+	return nil
+}
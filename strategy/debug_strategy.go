@@ -0,0 +1,98 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"strings"
+	"time"
+)
+
+// DebugSetStrategy makes a package wait for the debugger on every launch
+type DebugSetStrategy struct {
+	PackageName string
+	NoWait      bool
+	Persistent  bool
+}
+
+func (s *DebugSetStrategy) Run() error {
+	cmd := "adb shell am set-debug-app"
+	if !s.NoWait {
+		cmd += " -w"
+	}
+	if s.Persistent {
+		cmd += " --persistent"
+	}
+	if err := runAm(fmt.Sprintf("%s %s", cmd, s.PackageName)); err != nil {
+		return err
+	}
+	util.Log(fmt.Sprintf("%s is the debug app, start it and attach a debugger", s.PackageName))
+	return nil
+}
+
+// DebugClearStrategy clears the debug app
+type DebugClearStrategy struct{}
+
+func (s *DebugClearStrategy) Run() error {
+	if err := runAm("adb shell am clear-debug-app"); err != nil {
+		return err
+	}
+	util.Log("debug app has been cleared")
+	return nil
+}
+
+// DebugStartStrategy starts the launcher activity waiting for the debugger and forwards its JDWP port
+type DebugStartStrategy struct {
+	PackageName string
+	Component   string
+	Port        int
+	Timeout     time.Duration
+}
+
+func (s *DebugStartStrategy) Run() error {
+	component := s.Component
+	if component == "" {
+		var err error
+		if component, err = adb.ResolveLauncherActivity(s.PackageName); err != nil {
+			return err
+		}
+	}
+
+	// a running process would be brought to front without waiting for the debugger
+	if _, err := adb.Exec(fmt.Sprintf("adb shell am force-stop %s", s.PackageName), false, nil); err != nil {
+		return err
+	}
+	if err := runAm(fmt.Sprintf("adb shell am start -D -n %s", component)); err != nil {
+		return err
+	}
+
+	pid, err := adb.WaitForPID(s.PackageName, s.Timeout)
+	if err != nil {
+		return err
+	}
+	port, err := adb.ForwardJDWP(s.Port, pid)
+	if err != nil {
+		return err
+	}
+
+	util.Log(fmt.Sprintf("%s is waiting for the debugger", component))
+	util.Log(fmt.Sprintf("pid:  %d", pid))
+	util.Log(fmt.Sprintf("jdwp: localhost:%d -> jdwp:%d", port, pid))
+	util.Log(fmt.Sprintf("attach with Android Studio (Attach Debugger to Android Process) or: jdb -attach localhost:%d", port))
+	return nil
+}
+
+// runAm runs an am command, am reports failures on stdout or stderr
+func runAm(cmd string) error {
+	output, err := adb.Exec(cmd+" 2>&1", true, nil)
+	if err != nil {
+		return err
+	}
+	for _, line := range util.MultiLine(output) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Error") || strings.Contains(line, "Exception") {
+			return fmt.Errorf("%s", line)
+		}
+	}
+	return nil
+}
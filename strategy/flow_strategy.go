@@ -0,0 +1,191 @@
+package strategy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"rabbit-go/util"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FlowEvent is a screen shown during a recording session
+type FlowEvent struct {
+	Time   time.Time `json:"time"`
+	Offset float64   `json:"offsetSeconds"`
+	Screen Screen    `json:"screen"`
+	Name   string    `json:"name"`
+}
+
+// FlowTransition counts how often the user moved from one screen to another
+type FlowTransition struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// FlowSession is the exported recording
+type FlowSession struct {
+	Start       time.Time        `json:"start"`
+	End         time.Time        `json:"end"`
+	Events      []FlowEvent      `json:"events"`
+	Transitions []FlowTransition `json:"transitions"`
+}
+
+// RecordFlowStrategy polls the current screen and records the navigation history until interrupted
+type RecordFlowStrategy struct {
+	PackageName string
+	Interval    time.Duration
+	Duration    time.Duration
+	Output      string
+}
+
+func (s *RecordFlowStrategy) Run() error {
+	if s.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if s.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Duration)
+		defer cancel()
+	}
+
+	session := &FlowSession{Start: time.Now()}
+	util.Log("recording screen flow, press Ctrl + C to stop")
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	last := ""
+	for {
+		// a failed poll, e.g. a short usb disconnect, skips the tick instead of losing the session
+		screen, err := pollCurrentScreen()
+		if err == nil && (s.PackageName == "" || screen.PackageName == s.PackageName) {
+			if name := screen.Name(); name != last {
+				now := time.Now()
+				session.Events = append(session.Events, FlowEvent{
+					Time:   now,
+					Offset: now.Sub(session.Start).Seconds(),
+					Screen: screen,
+					Name:   name,
+				})
+				util.Log(fmt.Sprintf("%8.1fs %s", now.Sub(session.Start).Seconds(), name))
+				last = name
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			session.End = time.Now()
+			session.Transitions = flowTransitions(session.Events)
+			return s.export(session)
+		case <-ticker.C:
+		}
+	}
+}
+
+func flowTransitions(events []FlowEvent) []FlowTransition {
+	counts := make(map[[2]string]int)
+	var order [][2]string
+	for i := 1; i < len(events); i++ {
+		key := [2]string{events[i-1].Name, events[i].Name}
+		if counts[key] == 0 {
+			order = append(order, key)
+		}
+		counts[key]++
+	}
+
+	transitions := make([]FlowTransition, 0, len(order))
+	for _, key := range order {
+		transitions = append(transitions, FlowTransition{From: key[0], To: key[1], Count: counts[key]})
+	}
+	return transitions
+}
+
+func (s *RecordFlowStrategy) export(session *FlowSession) error {
+	prefix := s.Output
+	if prefix == "" {
+		prefix = session.Start.Format("2006_01_02_15_04_05") + "_flow"
+	}
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	files := map[string]string{
+		prefix + ".json":     string(data),
+		prefix + ".mmd":      flowMermaid(session),
+		prefix + ".dot":      flowGraphviz(session),
+		prefix + ".timeline": flowTimeline(session),
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := os.WriteFile(path, []byte(files[path]), 0644); err != nil {
+			return err
+		}
+		util.Log(fmt.Sprintf("flow has been saved in %s", path))
+	}
+	return nil
+}
+
+// flowNodeIDs assigns stable node ids in order of first appearance
+func flowNodeIDs(session *FlowSession) ([]string, map[string]string) {
+	ids := make(map[string]string)
+	var names []string
+	for _, e := range session.Events {
+		if _, ok := ids[e.Name]; !ok {
+			ids[e.Name] = fmt.Sprintf("s%d", len(names))
+			names = append(names, e.Name)
+		}
+	}
+	return names, ids
+}
+
+func flowMermaid(session *FlowSession) string {
+	names, ids := flowNodeIDs(session)
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "    %s[\"%s\"]\n", ids[name], strings.ReplaceAll(name, `"`, "#quot;"))
+	}
+	for _, t := range session.Transitions {
+		fmt.Fprintf(&sb, "    %s -->|%d| %s\n", ids[t.From], t.Count, ids[t.To])
+	}
+	return sb.String()
+}
+
+func flowGraphviz(session *FlowSession) string {
+	names, ids := flowNodeIDs(session)
+	var sb strings.Builder
+	sb.WriteString("digraph flow {\n    rankdir=LR;\n    node [shape=box];\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "    %s [label=%q];\n", ids[name], name)
+	}
+	for _, t := range session.Transitions {
+		fmt.Fprintf(&sb, "    %s -> %s [label=\"%d\"];\n", ids[t.From], ids[t.To], t.Count)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func flowTimeline(session *FlowSession) string {
+	var sb strings.Builder
+	for i, e := range session.Events {
+		end := session.End
+		if i+1 < len(session.Events) {
+			end = session.Events[i+1].Time
+		}
+		fmt.Fprintf(&sb, "%s  +%7.1fs  %6.1fs  %s\n",
+			e.Time.Format("15:04:05.000"), e.Offset, end.Sub(e.Time).Seconds(), e.Name)
+	}
+	return sb.String()
+}
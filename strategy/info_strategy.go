@@ -148,5 +148,6 @@ func (s *BatteryInfo) Run() error {
 		return err
 	}
 	util.Log(output)
+	util.Log(formatBattery(adb.ParseBatteryState(output)))
 	return nil
 }
@@ -0,0 +1,95 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"time"
+)
+
+// InputTapStrategy taps or long-presses coordinates or the element matched by a selector
+type InputTapStrategy struct {
+	X        int
+	Y        int
+	Selector *adb.UISelector
+	Index    int
+	Long     bool
+	Duration time.Duration
+}
+
+func (s *InputTapStrategy) Run() error {
+	x, y := s.X, s.Y
+	if s.Selector != nil && !s.Selector.IsEmpty() {
+		node, err := findUINode(s.Selector, s.Index)
+		if err != nil {
+			return err
+		}
+		x, y = node.Bounds.Center()
+		util.Log(fmt.Sprintf("%s -> (%d,%d)", node.Describe(), x, y))
+	}
+
+	if s.Long {
+		return adb.LongPress(x, y, s.Duration)
+	}
+	return adb.Tap(x, y)
+}
+
+func findUINode(selector *adb.UISelector, index int) (*adb.UINode, error) {
+	hierarchy, err := adb.DumpUIHierarchy()
+	if err != nil {
+		return nil, err
+	}
+	nodes := hierarchy.Find(selector)
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no element matches the selector")
+	}
+	if index < 0 || index >= len(nodes) {
+		return nil, fmt.Errorf("index %d out of range, %d elements match", index, len(nodes))
+	}
+	return nodes[index], nil
+}
+
+// InputSwipeStrategy swipes or drags between two points
+type InputSwipeStrategy struct {
+	X1       int
+	Y1       int
+	X2       int
+	Y2       int
+	Duration time.Duration
+	Drag     bool
+}
+
+func (s *InputSwipeStrategy) Run() error {
+	if s.Drag {
+		return adb.Drag(s.X1, s.Y1, s.X2, s.Y2, s.Duration)
+	}
+	return adb.Swipe(s.X1, s.Y1, s.X2, s.Y2, s.Duration)
+}
+
+// InputTextStrategy types text into the focused field
+type InputTextStrategy struct {
+	Text string
+}
+
+func (s *InputTextStrategy) Run() error {
+	return adb.InputText(s.Text)
+}
+
+// InputKeyStrategy sends named key events in order
+type InputKeyStrategy struct {
+	Keys []string
+	Long bool
+}
+
+func (s *InputKeyStrategy) Run() error {
+	for _, key := range s.Keys {
+		code, err := adb.KeyCode(key)
+		if err != nil {
+			return err
+		}
+		if err := adb.KeyEvent(code, s.Long); err != nil {
+			return err
+		}
+	}
+	return nil
+}
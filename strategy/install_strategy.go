@@ -0,0 +1,444 @@
+package strategy
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	splitABIs = map[string]string{
+		"armeabi":     "armeabi",
+		"armeabi_v7a": "armeabi-v7a",
+		"arm64_v8a":   "arm64-v8a",
+		"x86":         "x86",
+		"x86_64":      "x86_64",
+		"mips":        "mips",
+		"mips64":      "mips64",
+	}
+	splitDensities = map[string]int{
+		"ldpi":    120,
+		"mdpi":    160,
+		"tvdpi":   213,
+		"hdpi":    240,
+		"xhdpi":   320,
+		"xxhdpi":  480,
+		"xxxhdpi": 640,
+	}
+	installFailureRe = regexp.MustCompile(`(INSTALL_[A-Z_]+)(?::\s*([^\]\n]*))?`)
+	// "Success: created install session [1234]"
+	installSessionRe = regexp.MustCompile(`install session \[(\d+)\]`)
+)
+
+// hints for the install failures we run into most often
+var installFailureHints = map[string]string{
+	"INSTALL_FAILED_UPDATE_INCOMPATIBLE":      "the installed app is signed with a different key, uninstall it first",
+	"INSTALL_FAILED_VERSION_DOWNGRADE":        "the installed version is newer, use --downgrade",
+	"INSTALL_FAILED_ALREADY_EXISTS":           "the app is already installed, use --replace",
+	"INSTALL_FAILED_TEST_ONLY":                "the apk is marked testOnly, use --test-only",
+	"INSTALL_FAILED_INSUFFICIENT_STORAGE":     "not enough storage on the device",
+	"INSTALL_FAILED_NO_MATCHING_ABIS":         "the apk has no native libraries for the device abi",
+	"INSTALL_FAILED_OLDER_SDK":                "the device sdk is lower than the apk minSdkVersion",
+	"INSTALL_FAILED_MISSING_SPLIT":            "a required split apk is missing",
+	"INSTALL_FAILED_USER_RESTRICTED":          "installing over usb is disabled or was rejected on the device",
+	"INSTALL_FAILED_INVALID_APK":              "the apk or one of its splits is invalid",
+	"INSTALL_PARSE_FAILED_NO_CERTIFICATES":    "the apk is not signed",
+	"INSTALL_FAILED_DUPLICATE_PERMISSION":     "another installed app declares the same permission",
+	"INSTALL_FAILED_CONFLICTING_PROVIDER":     "another installed app declares the same content provider authority",
+	"INSTALL_PARSE_FAILED_MANIFEST_MALFORMED": "the AndroidManifest.xml is malformed",
+}
+
+// InstallStrategy installs a single apk, split apks or an .apks/.xapk/.zip bundle
+type InstallStrategy struct {
+	Files     []string
+	Replace   bool
+	Downgrade bool
+	GrantAll  bool
+	TestOnly  bool
+	User      string
+}
+
+func (s *InstallStrategy) Run() error {
+	if len(s.Files) == 0 {
+		return fmt.Errorf("no apk given")
+	}
+
+	apks := s.Files
+	var obbs []string
+	if len(s.Files) == 1 && isBundle(s.Files[0]) {
+		dir, err := os.MkdirTemp("", "rabbit-install-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		util.Log(fmt.Sprintf("extracting %s", s.Files[0]))
+		entries, err := extractBundle(s.Files[0], dir)
+		if err != nil {
+			return err
+		}
+		if apks, obbs, err = selectSplits(entries, adb.GetABIList(), adb.GetDensity()); err != nil {
+			return err
+		}
+		if len(apks) == 0 {
+			return fmt.Errorf("no apk found in %s", s.Files[0])
+		}
+		util.Log(fmt.Sprintf("selected splits:\n  %s", strings.Join(relativeNames(dir, apks), "\n  ")))
+	}
+
+	if err := s.installSession(apks); err != nil {
+		return err
+	}
+	util.Log("Success")
+	return pushObbs(obbs)
+}
+
+// installSession installs the apks in one `pm install-create` session. Every apk is streamed
+// into the session through `pm install-write` reading stdin, printing its progress, then committed.
+func (s *InstallStrategy) installSession(apks []string) error {
+	var total int64
+	for _, apk := range apks {
+		info, err := os.Stat(apk)
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+	}
+
+	output, err := adb.Exec(fmt.Sprintf("adb shell pm install-create %s -S %d 2>&1", s.options(), total), true, nil)
+	if err != nil {
+		return err
+	}
+	m := installSessionRe.FindStringSubmatch(output)
+	if m == nil {
+		return installError(output)
+	}
+	session := m[1]
+	committed := false
+	defer func() {
+		if !committed {
+			adb.Exec(fmt.Sprintf("adb shell pm install-abandon %s", session), true, nil)
+		}
+	}()
+	util.Log(fmt.Sprintf("created install session %s for %d apk(s), %.1f MB", session, len(apks), float64(total)/(1<<20)))
+
+	for i, apk := range apks {
+		name := fmt.Sprintf("%d_%s", i, filepath.Base(apk))
+		output, err := installWrite(session, name, apk)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(output, "Success") {
+			return installError(output)
+		}
+	}
+
+	util.Log(fmt.Sprintf("committing session %s", session))
+	output, err = adb.Exec(fmt.Sprintf("adb shell pm install-commit %s 2>&1", session), true, nil)
+	if err != nil {
+		return err
+	}
+	committed = true
+	if !strings.Contains(output, "Success") {
+		return installError(output)
+	}
+	return nil
+}
+
+// installWrite streams apk into the session as name and returns the pm output
+func installWrite(session, name, apk string) (string, error) {
+	f, err := os.Open(apk)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	remote := fmt.Sprintf("pm install-write -S %d %s %s - 2>&1", info.Size(), session, util.ShellQuote(name))
+	cmd := exec.Command("adb", "exec-out", remote)
+	progress := &installProgress{r: f, name: filepath.Base(apk), total: info.Size()}
+	cmd.Stdin = progress
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	progress.finish()
+	if err != nil && out.Len() == 0 {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// installProgress prints how much of an apk has been written into the session
+type installProgress struct {
+	r       io.Reader
+	name    string
+	total   int64
+	done    int64
+	percent int64
+}
+
+func (p *installProgress) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if p.total > 0 {
+		if percent := p.done * 100 / p.total; percent != p.percent {
+			p.percent = percent
+			fmt.Fprintf(os.Stderr, "\rwriting %s %3d%%", p.name, percent)
+		}
+	}
+	return n, err
+}
+
+func (p *installProgress) finish() {
+	fmt.Fprintf(os.Stderr, "\rwriting %s %3d%%\n", p.name, p.percent)
+}
+
+func (s *InstallStrategy) options() string {
+	var options []string
+	if s.Replace {
+		options = append(options, "-r")
+	}
+	if s.Downgrade {
+		options = append(options, "-d")
+	}
+	if s.GrantAll {
+		options = append(options, "-g")
+	}
+	if s.TestOnly {
+		options = append(options, "-t")
+	}
+	if s.User != "" {
+		options = append(options, "--user", adb.QuoteRemote(s.User))
+	}
+	return strings.Join(options, " ")
+}
+
+func installError(output string) error {
+	m := installFailureRe.FindStringSubmatch(output)
+	if m == nil {
+		return fmt.Errorf("install failed: %s", strings.TrimSpace(output))
+	}
+	msg := m[1]
+	if detail := strings.TrimSpace(m[2]); detail != "" {
+		msg += ": " + detail
+	}
+	if hint, ok := installFailureHints[m[1]]; ok {
+		msg += "\n" + hint
+	}
+	return fmt.Errorf("install failed, %s", msg)
+}
+
+func isBundle(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".apks", ".xapk", ".zip", ".apkm":
+		return true
+	}
+	return false
+}
+
+// extractBundle unpacks the apk and obb entries of a bundle into dir
+func extractBundle(path, dir string) ([]string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var extracted []string
+	for _, f := range r.File {
+		ext := strings.ToLower(filepath.Ext(f.Name))
+		if f.FileInfo().IsDir() || (ext != ".apk" && ext != ".obb") {
+			continue
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if !strings.HasPrefix(dest, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return nil, fmt.Errorf("invalid entry in bundle: %s", f.Name)
+		}
+		if err := extractZipFile(f, dest); err != nil {
+			return nil, err
+		}
+		extracted = append(extracted, dest)
+	}
+	sort.Strings(extracted)
+	return extracted, nil
+}
+
+func extractZipFile(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// selectSplits keeps the master splits, the best abi and density splits and all language splits.
+// bundletool standalone apks are only used when the bundle has no splits.
+func selectSplits(files []string, abis []string, density int) ([]string, []string, error) {
+	var apks, obbs, standalones []string
+	abiSplits := map[string][]string{}
+	densitySplits := map[string][]string{}
+
+	for _, f := range files {
+		if strings.EqualFold(filepath.Ext(f), ".obb") {
+			obbs = append(obbs, f)
+			continue
+		}
+		if strings.Contains(filepath.ToSlash(f), "/standalones/") {
+			standalones = append(standalones, f)
+			continue
+		}
+		qualifier := splitQualifier(f)
+		if abi, ok := splitABIs[qualifier]; ok {
+			abiSplits[abi] = append(abiSplits[abi], f)
+		} else if _, ok := splitDensities[qualifier]; ok {
+			densitySplits[qualifier] = append(densitySplits[qualifier], f)
+		} else {
+			apks = append(apks, f)
+		}
+	}
+
+	if len(apks) == 0 && len(standalones) > 0 {
+		standalone, err := selectStandalone(standalones, abis, density)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []string{standalone}, obbs, nil
+	}
+
+	for _, abi := range abis {
+		if splits, ok := abiSplits[abi]; ok {
+			apks = append(apks, splits...)
+			break
+		}
+	}
+	if bucket := bestDensity(densitySplits, density); bucket != "" {
+		apks = append(apks, densitySplits[bucket]...)
+	}
+	return apks, obbs, nil
+}
+
+// selectStandalone picks the standalone apk of the first device abi with the best density,
+// e.g. standalones/standalone-arm64_v8a_xxhdpi.apk
+func selectStandalone(standalones []string, abis []string, density int) (string, error) {
+	byABI := map[string]map[string][]string{}
+	for _, f := range standalones {
+		abi, dpi := standaloneQualifiers(f)
+		if byABI[abi] == nil {
+			byABI[abi] = map[string][]string{}
+		}
+		byABI[abi][dpi] = append(byABI[abi][dpi], f)
+	}
+
+	candidates := byABI[""]
+	for _, abi := range abis {
+		if c, ok := byABI[abi]; ok {
+			candidates = c
+			break
+		}
+	}
+	if candidates == nil {
+		return "", fmt.Errorf("no standalone apk matches the device abis %s", strings.Join(abis, ", "))
+	}
+	return candidates[bestDensity(candidates, density)][0], nil
+}
+
+// standaloneQualifiers returns the abi and density bucket of a standalone apk name, empty when absent
+func standaloneQualifiers(path string) (string, string) {
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	name = strings.Trim(strings.TrimPrefix(name, "standalone"), "-_")
+	name = strings.ReplaceAll(name, "-", "_")
+
+	abi := ""
+	match := ""
+	for qualifier, a := range splitABIs {
+		if (name == qualifier || strings.HasPrefix(name, qualifier+"_")) && len(qualifier) > len(match) {
+			abi, match = a, qualifier
+		}
+	}
+	name = strings.TrimPrefix(strings.TrimPrefix(name, match), "_")
+	if _, ok := splitDensities[name]; ok {
+		return abi, name
+	}
+	return abi, ""
+}
+
+// splitQualifier returns the config qualifier of a split file name,
+// e.g. "arm64_v8a" for base-arm64_v8a.apk or config.arm64_v8a.apk
+func splitQualifier(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name = strings.TrimPrefix(name, "split_")
+	if idx := strings.LastIndexAny(name, "-."); idx != -1 {
+		name = name[idx+1:]
+	}
+	return strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+}
+
+// bestDensity picks the smallest bucket not below the device density, or the largest one
+func bestDensity(splits map[string][]string, density int) string {
+	best := ""
+	for bucket := range splits {
+		dpi := splitDensities[bucket]
+		switch {
+		case best == "":
+			best = bucket
+		case dpi >= density && (splitDensities[best] < density || dpi < splitDensities[best]):
+			best = bucket
+		case splitDensities[best] < density && dpi > splitDensities[best]:
+			best = bucket
+		}
+	}
+	return best
+}
+
+func pushObbs(obbs []string) error {
+	for _, obb := range obbs {
+		// xapk keeps obbs under Android/obb/<package>/
+		slash := filepath.ToSlash(obb)
+		idx := strings.Index(slash, "Android/obb/")
+		if idx == -1 {
+			continue
+		}
+		dest := "/sdcard/" + slash[idx:]
+		util.Log(fmt.Sprintf("pushing %s", dest))
+		cmd := fmt.Sprintf("adb shell mkdir -p %s && adb push %s %s", adb.QuoteRemote(filepath.ToSlash(filepath.Dir(dest))), util.ShellQuote(obb), util.ShellQuote(dest))
+		if _, err := adb.Exec(cmd, false, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func relativeNames(dir string, files []string) []string {
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		if rel, err := filepath.Rel(dir, f); err == nil {
+			f = rel
+		}
+		names = append(names, f)
+	}
+	return names
+}
@@ -0,0 +1,94 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"strings"
+)
+
+var intentCommands = map[string]string{
+	"activity":  "am start",
+	"service":   "am startservice",
+	"broadcast": "am broadcast",
+}
+
+// IntentStrategy resolves an intent against the installed intent filters and sends it
+type IntentStrategy struct {
+	Target     string
+	Intent     *adb.Intent
+	Foreground bool
+	User       string
+	DryRun     bool
+}
+
+func (s *IntentStrategy) Run() error {
+	command, ok := intentCommands[s.Target]
+	if !ok {
+		return fmt.Errorf("unknown intent target: %s (activity|service|broadcast)", s.Target)
+	}
+	if s.Target == "service" && s.Foreground {
+		command = "am start-foreground-service"
+	}
+	if s.User != "" {
+		command += " --user " + adb.QuoteRemote(s.User)
+	}
+	if s.Target == "broadcast" && s.Intent.Component == "" && s.Intent.Package == "" {
+		util.LogE("implicit broadcasts do not reach manifest receivers on Android 8+, pass -p or -n to make it explicit")
+	}
+
+	matches, err := adb.ResolveIntent(s.Target, s.Intent)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		util.LogE(fmt.Sprintf("no %s matches the intent", s.Target))
+	} else {
+		util.Log(fmt.Sprintf("matching %s components:\n  %s", s.Target, strings.Join(matches, "\n  ")))
+	}
+
+	if s.Target == "activity" {
+		resolved, err := adb.ResolveActivity(s.Intent)
+		if err != nil {
+			return err
+		}
+		if resolved != "" {
+			util.Log(fmt.Sprintf("resolves to: %s", resolved))
+		}
+	}
+
+	args, err := s.Intent.Args()
+	if err != nil {
+		return err
+	}
+	cmd := fmt.Sprintf("adb shell %s %s", command, args)
+	if s.DryRun {
+		util.Log(cmd)
+		return nil
+	}
+
+	output, err := adb.Exec(cmd+" 2>&1", true, nil)
+	if err != nil {
+		return err
+	}
+	output = strings.TrimSpace(output)
+	if err := amError(output); err != nil {
+		return err
+	}
+	util.Log(output)
+	return nil
+}
+
+// amError returns the failure am reported, only at the start of a line so that a broadcast result
+// or data string containing "Error" is not mistaken for one
+func amError(output string) error {
+	for _, line := range util.MultiLine(output) {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"Error:", "Error type", "Exception"} {
+			if strings.HasPrefix(line, prefix) {
+				return fmt.Errorf("%s", output)
+			}
+		}
+	}
+	return nil
+}
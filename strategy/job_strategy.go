@@ -0,0 +1,89 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"strings"
+	"text/tabwriter"
+)
+
+// JobListStrategy prints the JobScheduler jobs of a package, or of every package
+type JobListStrategy struct {
+	PackageName string
+	JSON        bool
+}
+
+func (s *JobListStrategy) Run() error {
+	jobs, err := adb.GetJobs(s.PackageName)
+	if err != nil {
+		return err
+	}
+
+	if s.JSON {
+		return logJSON(jobs)
+	}
+	if len(jobs) == 0 {
+		util.Log("no jobs")
+		return nil
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSERVICE\tCONSTRAINTS\tUNSATISFIED\tINTERVAL\tLAST RUN\tNEXT RUN\tSTATE")
+	for _, job := range jobs {
+		state := job.State
+		if state == "" && job.Ready {
+			state = "ready"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", job.ID, job.Service,
+			orDash(strings.Join(job.Constraints, ",")), orDash(strings.Join(job.Unsatisfied, ",")),
+			orDash(job.Interval), orDash(job.LastRun), orDash(job.NextRun), orDash(state))
+	}
+	w.Flush()
+	util.Log(strings.TrimRight(sb.String(), "\n"))
+	return nil
+}
+
+// JobRunStrategy runs jobs now instead of waiting for their constraints and delays
+type JobRunStrategy struct {
+	PackageName string
+	IDs         []int
+	All         bool
+	Force       bool
+	User        string
+}
+
+func (s *JobRunStrategy) Run() error {
+	ids := s.IDs
+	if s.All {
+		jobs, err := adb.GetJobs(s.PackageName)
+		if err != nil {
+			return err
+		}
+		ids = nil
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		if len(ids) == 0 {
+			return fmt.Errorf("%s has no scheduled jobs", s.PackageName)
+		}
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("no job id given, pass ids or --all")
+	}
+
+	failed := 0
+	for _, id := range ids {
+		if err := adb.RunJob(s.PackageName, id, s.Force, s.User); err != nil {
+			util.LogE(err.Error())
+			failed++
+			continue
+		}
+		util.Log(fmt.Sprintf("job %d is running", id))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d job(s) could not run", failed, len(ids))
+	}
+	return nil
+}
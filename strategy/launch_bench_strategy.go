@@ -0,0 +1,238 @@
+package strategy
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LaunchModeCold = "cold"
+	LaunchModeWarm = "warm"
+	LaunchModeHot  = "hot"
+)
+
+// LaunchRun is a single measured launch
+type LaunchRun struct {
+	Run         int    `json:"run"`
+	Mode        string `json:"mode"`
+	LaunchState string `json:"launchState"`
+	TotalTime   int    `json:"totalTime"`
+	WaitTime    int    `json:"waitTime"`
+	Displayed   int    `json:"displayed"`
+}
+
+// LaunchStats summarizes one metric over all runs, in ms
+type LaunchStats struct {
+	Min    int `json:"min"`
+	Median int `json:"median"`
+	P90    int `json:"p90"`
+	Max    int `json:"max"`
+}
+
+// LaunchBenchReport is the exported benchmark result
+type LaunchBenchReport struct {
+	Package   string                 `json:"package"`
+	Component string                 `json:"component"`
+	Mode      string                 `json:"mode"`
+	Runs      []LaunchRun            `json:"runs"`
+	Summary   map[string]LaunchStats `json:"summary"`
+}
+
+// LaunchBenchStrategy measures cold, warm or hot launch times with `am start -W`
+type LaunchBenchStrategy struct {
+	PackageName string
+	Component   string
+	Runs        int
+	Mode        string
+	DropCaches  bool
+	Delay       time.Duration
+	CSVPath     string
+	JSONPath    string
+}
+
+func (s *LaunchBenchStrategy) Run() error {
+	if s.Mode != LaunchModeCold && s.Mode != LaunchModeWarm && s.Mode != LaunchModeHot {
+		return fmt.Errorf("unknown launch mode: %s (cold|warm|hot)", s.Mode)
+	}
+	if s.Runs <= 0 {
+		return fmt.Errorf("runs must be greater than 0")
+	}
+
+	component := s.Component
+	if component == "" {
+		resolved, err := adb.ResolveLauncherActivity(s.PackageName)
+		if err != nil {
+			return err
+		}
+		component = resolved
+	}
+
+	if s.Mode != LaunchModeCold {
+		// warm and hot launches need a running process, the first launch is not measured
+		if _, err := adb.StartActivityAndWait(component); err != nil {
+			return err
+		}
+		time.Sleep(s.Delay)
+	}
+
+	report := &LaunchBenchReport{Package: s.PackageName, Component: component, Mode: s.Mode}
+	for i := 1; i <= s.Runs; i++ {
+		if err := s.prepare(); err != nil {
+			return err
+		}
+		_, _ = adb.Exec("adb logcat -c", true, nil)
+
+		result, err := adb.StartActivityAndWait(component, s.launchOptions()...)
+		if err != nil {
+			return err
+		}
+		logcat, _ := adb.Exec("adb logcat -d -s ActivityTaskManager:I ActivityManager:I", true, nil)
+
+		run := LaunchRun{
+			Run:         i,
+			Mode:        s.Mode,
+			LaunchState: result.LaunchState,
+			TotalTime:   result.TotalTime,
+			WaitTime:    result.WaitTime,
+			Displayed:   adb.ParseDisplayedTime(logcat, component),
+		}
+		report.Runs = append(report.Runs, run)
+		util.Log(fmt.Sprintf("run %d/%d: TotalTime=%dms WaitTime=%dms Displayed=%dms %s",
+			i, s.Runs, run.TotalTime, run.WaitTime, run.Displayed, run.LaunchState))
+
+		time.Sleep(s.Delay)
+	}
+
+	report.Summary = summarizeLaunchRuns(report.Runs)
+	util.Log(formatLaunchSummary(report))
+
+	if s.CSVPath != "" {
+		if err := writeLaunchCSV(s.CSVPath, report); err != nil {
+			return err
+		}
+		util.Log(fmt.Sprintf("csv has been saved in %s", s.CSVPath))
+	}
+	if s.JSONPath != "" {
+		if err := writeLaunchJSON(s.JSONPath, report); err != nil {
+			return err
+		}
+		util.Log(fmt.Sprintf("json has been saved in %s", s.JSONPath))
+	}
+	return nil
+}
+
+// prepare brings the app into the state required by the launch mode
+func (s *LaunchBenchStrategy) prepare() error {
+	switch s.Mode {
+	case LaunchModeCold:
+		if _, err := adb.Exec(fmt.Sprintf("adb shell am force-stop %s", s.PackageName), false, nil); err != nil {
+			return err
+		}
+		if s.DropCaches {
+			output, _ := adb.Exec(`adb shell "sync; echo 3 > /proc/sys/vm/drop_caches"`, true, nil)
+			if strings.Contains(output, "denied") || strings.Contains(output, "not permitted") {
+				util.LogE("drop caches needs a rooted device (adb root)")
+			}
+		}
+	case LaunchModeWarm, LaunchModeHot:
+		// back no longer finishes a root activity on Android 12+, warm launches clear the task instead
+		_, err := adb.Exec("adb shell input keyevent KEYCODE_HOME", false, nil)
+		return err
+	}
+	return nil
+}
+
+// launchOptions makes warm launches finish the activities of the task and create a new one
+// in the running process, with FLAG_ACTIVITY_NEW_TASK | FLAG_ACTIVITY_CLEAR_TASK
+func (s *LaunchBenchStrategy) launchOptions() []string {
+	if s.Mode == LaunchModeWarm {
+		return []string{"-f", "0x10008000"}
+	}
+	return nil
+}
+
+func summarizeLaunchRuns(runs []LaunchRun) map[string]LaunchStats {
+	metrics := map[string][]int{}
+	for _, r := range runs {
+		for name, value := range map[string]int{"totalTime": r.TotalTime, "waitTime": r.WaitTime, "displayed": r.Displayed} {
+			if value >= 0 {
+				metrics[name] = append(metrics[name], value)
+			}
+		}
+	}
+
+	summary := make(map[string]LaunchStats)
+	for name, values := range metrics {
+		sort.Ints(values)
+		summary[name] = LaunchStats{
+			Min:    values[0],
+			Median: percentile(values, 50),
+			P90:    percentile(values, 90),
+			Max:    values[len(values)-1],
+		}
+	}
+	return summary
+}
+
+// percentile uses the nearest-rank method on sorted values
+func percentile(sorted []int, p float64) int {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func formatLaunchSummary(report *LaunchBenchReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s launch, %d runs\n", report.Component, report.Mode, len(report.Runs))
+	fmt.Fprintf(&sb, "%-10s %8s %8s %8s %8s\n", "metric", "min", "median", "p90", "max")
+	for _, name := range []string{"totalTime", "waitTime", "displayed"} {
+		stats, ok := report.Summary[name]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&sb, "%-10s %8d %8d %8d %8d\n", name, stats.Min, stats.Median, stats.P90, stats.Max)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func writeLaunchCSV(path string, report *LaunchBenchReport) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	_ = w.Write([]string{"run", "mode", "launch_state", "total_time", "wait_time", "displayed"})
+	for _, r := range report.Runs {
+		_ = w.Write([]string{
+			strconv.Itoa(r.Run),
+			r.Mode,
+			r.LaunchState,
+			strconv.Itoa(r.TotalTime),
+			strconv.Itoa(r.WaitTime),
+			strconv.Itoa(r.Displayed),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeLaunchJSON(path string, report *LaunchBenchReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -5,6 +5,7 @@ import (
 	"rabbit-go/adb"
 	"rabbit-go/config"
 	"rabbit-go/util"
+	"strings"
 )
 
 type LogStrategy interface {
@@ -49,15 +50,85 @@ func (s *LogAllFragmentStrategy) CanHandle(packageName string, config config.Log
 }
 
 func (s *LogAllFragmentStrategy) Run(packageName string, config config.LogConfig) error {
-	cmd := fmt.Sprintf(`adb shell dumpsys activity %s | grep -E '^\s*#\d' | grep -v -E 'ReportFragment|plan'`, packageName)
-	res, err := adb.Exec(cmd, false, nil)
+	output, err := adb.GetFragmentDump(packageName)
 	if err != nil {
 		return err
 	}
-	util.Log(res)
+
+	activities := adb.ParseFragmentDump(output)
+	if len(activities) == 0 {
+		util.Log(fmt.Sprintf("no activity of %s found", packageName))
+		return nil
+	}
+
+	var sb strings.Builder
+	for _, activity := range activities {
+		state := "paused"
+		if activity.Resumed {
+			state = "resumed"
+		}
+		fmt.Fprintf(&sb, "%s pid=%s (%s)\n", activity.Component, activity.Pid, state)
+		if len(activity.Managers) == 0 {
+			sb.WriteString("  no fragments\n")
+		}
+		for _, manager := range activity.Managers {
+			writeFragmentManager(&sb, manager, 1)
+		}
+	}
+	util.Log(strings.TrimSuffix(sb.String(), "\n"))
 	return nil
 }
 
+func writeFragmentManager(sb *strings.Builder, manager *adb.FragmentManagerDump, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(sb, "%sFragmentManager (curState=%d)\n", indent, manager.CurState)
+
+	for _, f := range manager.Fragments {
+		var attrs []string
+		if f.Tag != "" {
+			attrs = append(attrs, "tag="+f.Tag)
+		}
+		if f.ID != "" {
+			attrs = append(attrs, "id="+f.ID)
+		}
+		if f.ContainerID != "" {
+			attrs = append(attrs, "container="+f.ContainerID)
+		}
+		attrs = append(attrs, "state="+f.StateName())
+		if f.Hidden {
+			attrs = append(attrs, "hidden")
+		}
+		if f.Detached {
+			attrs = append(attrs, "detached")
+		}
+		if !f.Added {
+			attrs = append(attrs, "not-added")
+		}
+
+		marker := " "
+		if f.Visible {
+			marker = "*"
+		} else if f.Resumed {
+			marker = "+"
+		}
+		fmt.Fprintf(sb, "%s  %s %s{%s} %s\n", indent, marker, f.Name, f.Hash, strings.Join(attrs, " "))
+		if f.Children != nil && (len(f.Children.Fragments) > 0 || len(f.Children.BackStack) > 0) {
+			writeFragmentManager(sb, f.Children, depth+2)
+		}
+	}
+
+	if len(manager.BackStack) > 0 {
+		fmt.Fprintf(sb, "%s  Back Stack:\n", indent)
+		for _, entry := range manager.BackStack {
+			name := entry.Name
+			if name == "" {
+				name = "<unnamed>"
+			}
+			fmt.Fprintf(sb, "%s    #%s %s %s\n", indent, entry.Index, name, strings.Join(entry.Ops, ", "))
+		}
+	}
+}
+
 type LogSpecificPackageActivityStrategy struct{}
 
 func (s *LogSpecificPackageActivityStrategy) CanHandle(packageName string, config config.LogConfig) bool {
@@ -78,3 +149,87 @@ func (s *LogSpecificPackageActivityStrategy) Run(packageName string, config conf
 	util.Log(res)
 	return nil
 }
+
+// Screen is the resumed activity together with its visible fragments
+type Screen struct {
+	PackageName string   `json:"package"`
+	Activity    string   `json:"activity"`
+	Fragments   []string `json:"fragments,omitempty"`
+}
+
+// ActivityClass returns the fully qualified activity class name
+func (s Screen) ActivityClass() string {
+	if strings.HasPrefix(s.Activity, ".") {
+		return s.PackageName + s.Activity
+	}
+	return s.Activity
+}
+
+// Name returns a short, stable name of the screen
+func (s Screen) Name() string {
+	name := s.ActivityClass()
+	name = name[strings.LastIndex(name, ".")+1:]
+	if len(s.Fragments) > 0 {
+		name += " > " + strings.Join(s.Fragments, " > ")
+	}
+	return name
+}
+
+// resolveCurrentScreen combines the current activity with the visible fragments of the fragment tree
+func resolveCurrentScreen() (Screen, error) {
+	return resolveScreen(false)
+}
+
+// pollCurrentScreen is resolveCurrentScreen for polling loops, adb errors are returned instead of exiting
+func pollCurrentScreen() (Screen, error) {
+	return resolveScreen(true)
+}
+
+func resolveScreen(poll bool) (Screen, error) {
+	getActivity, getFragmentDump := adb.GetCurrentPackageAndActivityName, adb.GetFragmentDump
+	if poll {
+		getActivity, getFragmentDump = adb.TryGetCurrentPackageAndActivityName, adb.TryGetFragmentDump
+	}
+	res, err := getActivity()
+	if err != nil {
+		return Screen{}, err
+	}
+	component := strings.TrimSpace(res)
+	parts := strings.SplitN(component, "/", 2)
+	if len(parts) != 2 {
+		return Screen{}, fmt.Errorf("invalid package/activity format: %s", component)
+	}
+	screen := Screen{PackageName: parts[0], Activity: parts[1]}
+
+	output, err := getFragmentDump(screen.PackageName)
+	if err != nil {
+		// a poll without fragments would be recorded as a transition, skip it instead
+		if poll {
+			return Screen{}, err
+		}
+		return screen, nil
+	}
+	for _, a := range adb.ParseFragmentDump(output) {
+		if !a.Resumed {
+			continue
+		}
+		for _, manager := range a.Managers {
+			screen.Fragments = appendVisibleFragments(screen.Fragments, manager)
+		}
+	}
+	return screen, nil
+}
+
+func appendVisibleFragments(names []string, manager *adb.FragmentManagerDump) []string {
+	if manager == nil {
+		return names
+	}
+	for _, f := range manager.Fragments {
+		if !f.Visible {
+			continue
+		}
+		names = append(names, f.Name)
+		names = appendVisibleFragments(names, f.Children)
+	}
+	return names
+}
@@ -0,0 +1,171 @@
+package strategy
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/signal"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// memSummaryCategories are the App Summary categories shown in the report and the csv
+var memSummaryCategories = []string{"Java Heap", "Native Heap", "Code", "Graphics", "Stack"}
+
+// memLeakObjects are the object counts that hint at leaks
+var memLeakObjects = []string{"Views", "Activities", "AppContexts", "ViewRootImpl", "WebViews"}
+
+// activityGrowthSamples is the number of consecutive samples above the first
+// Activities count after which the count is flagged as growing
+const activityGrowthSamples = 3
+
+// AppMemStrategy prints the memory of an app, or samples it into a csv with Watch
+type AppMemStrategy struct {
+	PackageName string
+	JSON        bool
+	Watch       time.Duration
+	Duration    time.Duration
+	CSVPath     string
+}
+
+func (s *AppMemStrategy) Run() error {
+	if s.Watch > 0 {
+		return s.watch()
+	}
+
+	info, err := adb.GetMemInfo(s.PackageName)
+	if err != nil {
+		return err
+	}
+	if s.JSON {
+		return logJSON(info)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (pid %d)\n\n", info.Process, info.PID)
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "CATEGORY\tPSS\tRSS\tPRIVATE DIRTY\t")
+	for _, name := range memSummaryCategories {
+		c := info.Category(name)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", name, formatKB(c.PSS), formatKB(c.RSS), formatKB(c.PrivateDirty))
+	}
+	fmt.Fprintf(w, "TOTAL\t%s\t%s\t%s\t\n", formatKB(info.TotalPSS), formatKB(info.TotalRSS), formatKB(memTotalRow(info)))
+	w.Flush()
+
+	sb.WriteString("\n")
+	for _, name := range memLeakObjects {
+		fmt.Fprintf(&sb, "%-14s%d\n", name+":", info.Objects[name])
+	}
+	util.Log(strings.TrimRight(sb.String(), "\n"))
+	return nil
+}
+
+func (s *AppMemStrategy) watch() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if s.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Duration)
+		defer cancel()
+	}
+
+	path := s.CSVPath
+	if path == "" {
+		path = fmt.Sprintf("%s_%s_mem.csv", time.Now().Format("2006_01_02_15_04_05"), s.PackageName)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := csv.NewWriter(file)
+
+	header := []string{"time", "pid", "total_pss_kb", "total_rss_kb"}
+	for _, name := range memSummaryCategories {
+		header = append(header, csvColumn(name)+"_pss_kb")
+	}
+	for _, name := range memLeakObjects {
+		header = append(header, csvColumn(name))
+	}
+	header = append(header, "flag")
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	util.Log(fmt.Sprintf("sampling %s every %s into %s, press Ctrl + C to stop", s.PackageName, s.Watch, path))
+	ticker := time.NewTicker(s.Watch)
+	defer ticker.Stop()
+
+	baseline, above := -1, 0
+	for {
+		// an adb error, e.g. a short usb disconnect, skips the sample instead of cutting the csv off
+		info, err := adb.TryGetMemInfo(s.PackageName)
+		if err != nil {
+			util.LogE(err.Error())
+		} else {
+			activities := info.Objects["Activities"]
+			if baseline == -1 {
+				baseline = activities
+			}
+			if activities > baseline {
+				above++
+			} else {
+				above = 0
+			}
+			flag := ""
+			if above >= activityGrowthSamples {
+				flag = "activities-growing"
+			}
+
+			record := []string{time.Now().Format(time.RFC3339), strconv.Itoa(info.PID), strconv.Itoa(info.TotalPSS), strconv.Itoa(info.TotalRSS)}
+			for _, name := range memSummaryCategories {
+				record = append(record, strconv.Itoa(info.Category(name).PSS))
+			}
+			for _, name := range memLeakObjects {
+				record = append(record, strconv.Itoa(info.Objects[name]))
+			}
+			if err := w.Write(append(record, flag)); err != nil {
+				return err
+			}
+			w.Flush()
+
+			line := fmt.Sprintf("pss %s  java %s  native %s  activities %d  views %d", formatKB(info.TotalPSS),
+				formatKB(info.Category("Java Heap").PSS), formatKB(info.Category("Native Heap").PSS), activities, info.Objects["Views"])
+			if flag != "" {
+				util.LogE(fmt.Sprintf("%s  ! activities grew from %d to %d, possible leak", line, baseline, activities))
+			} else {
+				util.Log(line)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			w.Flush()
+			util.Log(fmt.Sprintf("samples have been saved in %s", path))
+			return w.Error()
+		case <-ticker.C:
+		}
+	}
+}
+
+func memTotalRow(info *adb.MemInfo) int {
+	for _, row := range info.Rows {
+		if row.Name == "TOTAL" {
+			return row.Values["Private Dirty"]
+		}
+	}
+	return 0
+}
+
+func csvColumn(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", "_"))
+}
+
+func formatKB(kb int) string {
+	return formatSize(int64(kb) * 1024)
+}
@@ -0,0 +1,90 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// NetStatus is the radio switches and the connected networks
+type NetStatus struct {
+	Wifi     bool           `json:"wifi"`
+	Data     bool           `json:"data"`
+	Airplane bool           `json:"airplane"`
+	Networks []*adb.Network `json:"networks"`
+}
+
+// NetStrategy toggles wifi, mobile data and airplane mode and reports the network state
+type NetStrategy struct {
+	Action  string
+	Enabled bool
+	Wait    time.Duration
+	JSON    bool
+}
+
+func (s *NetStrategy) Run() error {
+	var err error
+	switch s.Action {
+	case "status":
+		return s.status()
+	case "wifi":
+		err = adb.SetWifiEnabled(s.Enabled)
+	case "data":
+		err = adb.SetMobileDataEnabled(s.Enabled)
+	case "airplane":
+		err = adb.SetAirplaneMode(s.Enabled)
+	default:
+		return fmt.Errorf("unknown action %s", s.Action)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot switch %s: %w", s.Action, err)
+	}
+
+	util.Log(fmt.Sprintf("%s has been turned %s", s.Action, onOff(s.Enabled)))
+	// the connectivity service needs a moment to bring networks up or down
+	time.Sleep(s.Wait)
+	return s.status()
+}
+
+func (s *NetStrategy) status() error {
+	networks, err := adb.GetNetworks()
+	if err != nil {
+		return err
+	}
+	status := &NetStatus{Networks: networks}
+	status.Wifi, status.Data, status.Airplane = adb.RadioStates()
+
+	if s.JSON {
+		return logJSON(status)
+	}
+	util.Log(formatNetStatus(status))
+	return nil
+}
+
+func formatNetStatus(status *NetStatus) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "wifi %s, data %s, airplane %s\n\n", onOff(status.Wifi), onOff(status.Data), onOff(status.Airplane))
+	if len(status.Networks) == 0 {
+		sb.WriteString("no connected network")
+		return sb.String()
+	}
+
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NETWORK\tTYPE\tINTERFACE\tDEFAULT\tVALIDATED\tMETERED\tADDRESSES\tDNS")
+	for _, n := range status.Networks {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%t\t%t\t%s\t%s\n", n.ID, orDash(n.Type), orDash(n.Interface), n.Default, n.Validated, n.Metered,
+			orDash(strings.Join(n.Addresses, ",")), orDash(strings.Join(n.DNS, ",")))
+	}
+	w.Flush()
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
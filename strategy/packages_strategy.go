@@ -0,0 +1,129 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// PackagesStrategy lists installed packages with filters
+type PackagesStrategy struct {
+	ThirdParty bool
+	System     bool
+	Disabled   bool
+	Debuggable bool
+	Installer  string
+	Pattern    string
+	JSON       bool
+}
+
+func (s *PackagesStrategy) Run() error {
+	var pattern *regexp.Regexp
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		pattern = re
+	}
+
+	packages, err := adb.ListPackages()
+	if err != nil {
+		return err
+	}
+
+	var filtered []*adb.PackageInfo
+	for _, p := range packages {
+		if s.match(p, pattern) {
+			filtered = append(filtered, p)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Name < filtered[j].Name
+	})
+
+	if s.JSON {
+		return logJSON(filtered)
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tVERSION\tCODE\tTARGET\tINSTALLED\tUPDATED\tSIZE\tINSTALLER\tFLAGS")
+	for _, p := range filtered {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			p.Name,
+			orDash(p.VersionName),
+			orDash(p.VersionCode),
+			orDash(p.TargetSDK),
+			orDash(p.FirstInstallTime),
+			orDash(p.LastUpdateTime),
+			formatSize(p.Size),
+			orDash(p.Installer),
+			packageMarks(p),
+		)
+	}
+	w.Flush()
+	util.Log(fmt.Sprintf("%s%d packages", sb.String(), len(filtered)))
+	return nil
+}
+
+func (s *PackagesStrategy) match(p *adb.PackageInfo, pattern *regexp.Regexp) bool {
+	if s.ThirdParty && p.System {
+		return false
+	}
+	if s.System && !p.System {
+		return false
+	}
+	if s.Disabled && !p.Disabled {
+		return false
+	}
+	if s.Debuggable && !p.Debuggable() {
+		return false
+	}
+	if s.Installer != "" && p.Installer != s.Installer {
+		return false
+	}
+	if pattern != nil && !pattern.MatchString(p.Name) {
+		return false
+	}
+	return true
+}
+
+func packageMarks(p *adb.PackageInfo) string {
+	var marks []string
+	if p.System {
+		marks = append(marks, "system")
+	}
+	if p.Disabled {
+		marks = append(marks, "disabled")
+	}
+	if p.Debuggable() {
+		marks = append(marks, "debuggable")
+	}
+	return strings.Join(marks, ",")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size <= 0:
+		return "-"
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1fG", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1fM", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1fK", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", size)
+}
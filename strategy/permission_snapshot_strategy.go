@@ -0,0 +1,174 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"slices"
+	"time"
+)
+
+// PermissionSnapshot is the saved runtime permission state of an app
+type PermissionSnapshot struct {
+	Package     string                `json:"package"`
+	User        string                `json:"user"`
+	Created     string                `json:"created"`
+	Permissions []adb.PermissionState `json:"permissions"`
+}
+
+// PermSnapshotStrategy saves the runtime permission grant state and flags of an app
+type PermSnapshotStrategy struct {
+	PackageName string
+	Name        string
+	Dir         string
+	User        string
+}
+
+func (s *PermSnapshotStrategy) Run() error {
+	info, err := adb.GetPackageInfo(s.PackageName)
+	if err != nil {
+		return err
+	}
+	user, err := packageUserState(info, s.User)
+	if err != nil {
+		return err
+	}
+
+	snapshot := &PermissionSnapshot{
+		Package:     s.PackageName,
+		User:        user.ID,
+		Created:     time.Now().Format(time.RFC3339),
+		Permissions: user.RuntimePermissions,
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := snapshotPath(s.Dir, s.PackageName, s.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	util.Log(fmt.Sprintf("%d runtime permission(s) have been saved in %s", len(snapshot.Permissions), path))
+	return nil
+}
+
+// PermRestoreStrategy reapplies a snapshot taken by PermSnapshotStrategy
+type PermRestoreStrategy struct {
+	PackageName string
+	Name        string
+	Dir         string
+	User        string
+}
+
+func (s *PermRestoreStrategy) Run() error {
+	path := snapshotPath(s.Dir, s.PackageName, s.Name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var snapshot PermissionSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("invalid snapshot %s: %w", path, err)
+	}
+
+	info, err := adb.GetPackageInfo(s.PackageName)
+	if err != nil {
+		return err
+	}
+	userID := s.User
+	if userID == "" {
+		userID = snapshot.User
+	}
+	user, err := packageUserState(info, userID)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]adb.PermissionState)
+	for _, state := range user.RuntimePermissions {
+		current[state.Name] = state
+	}
+
+	// permission flags can only be changed from the shell since Android 11
+	withFlags := adb.GetSDKVersion() >= 30
+	if !withFlags {
+		util.LogE("permission flags need Android 11, only the grant state is restored")
+	}
+
+	var results []permissionResult
+	for _, saved := range snapshot.Permissions {
+		state, ok := current[saved.Name]
+		result := permissionResult{name: saved.Name, status: "skipped"}
+		switch {
+		case !ok:
+			result.reason = "no longer requested by the app"
+		case state.Granted == saved.Granted && (!withFlags || slices.Equal(restorableFlags(state), restorableFlags(saved))):
+			result.reason = "unchanged"
+		default:
+			if err := restorePermission(s.PackageName, user.ID, state, saved, withFlags); err != nil {
+				result.status, result.reason = "failed", err.Error()
+			} else {
+				result.status = "restored"
+			}
+		}
+		results = append(results, result)
+	}
+	return reportPermissionResults("restore", "restored", results)
+}
+
+// restorePermission clears the flags first since user-fixed blocks grant changes, then applies the saved state
+func restorePermission(packageName, user string, state, saved adb.PermissionState, withFlags bool) error {
+	if withFlags {
+		if err := adb.ClearPermissionFlags(packageName, state.Name, user, adb.RestorablePermissionFlags()); err != nil {
+			return err
+		}
+	}
+
+	change := adb.RevokePermission
+	if saved.Granted {
+		change = adb.GrantPermission
+	}
+	if err := change(packageName, saved.Name, user); err != nil {
+		return err
+	}
+
+	if flags := restorableFlags(saved); withFlags && len(flags) > 0 {
+		return adb.SetPermissionFlags(packageName, saved.Name, user, flags)
+	}
+	return nil
+}
+
+func restorableFlags(state adb.PermissionState) []string {
+	var flags []string
+	for _, flag := range state.Flags {
+		if name := adb.PermissionFlagName(flag); slices.Contains(adb.RestorablePermissionFlags(), name) {
+			flags = append(flags, name)
+		}
+	}
+	slices.Sort(flags)
+	return flags
+}
+
+func packageUserState(info *adb.PackageInfo, user string) (*adb.PackageUserState, error) {
+	for i, u := range info.Users {
+		if user == "" || u.ID == user {
+			return &info.Users[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not installed for user %s", info.Name, user)
+}
+
+// snapshotPath returns <dir>/<package>/<name>.json, dir defaults to ~/.rabbit-go/permissions
+func snapshotPath(dir, packageName, name string) string {
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".rabbit-go", "permissions")
+	}
+	return filepath.Join(dir, packageName, name+".json")
+}
@@ -0,0 +1,193 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// PermListStrategy lists the requested permissions of an app with their grant state
+type PermListStrategy struct {
+	PackageName string
+	User        string
+	JSON        bool
+}
+
+type permissionEntry struct {
+	Name       string   `json:"name"`
+	Group      string   `json:"group,omitempty"`
+	Protection string   `json:"protection,omitempty"`
+	State      string   `json:"state"`
+	Flags      []string `json:"flags,omitempty"`
+}
+
+func (s *PermListStrategy) Run() error {
+	info, err := adb.GetPackageInfo(s.PackageName)
+	if err != nil {
+		return err
+	}
+	defs, err := adb.ListPermissionDefs()
+	if err != nil {
+		return err
+	}
+	states := info.PermissionStates(s.User)
+
+	var entries []permissionEntry
+	for _, name := range info.RequestedPermissions {
+		def := defs[name]
+		entry := permissionEntry{Name: name, Group: def.Group, Protection: def.Protection, State: "-"}
+		if state, ok := states[name]; ok {
+			entry.State = "denied"
+			if state.Granted {
+				entry.State = "granted"
+			}
+			for _, flag := range state.Flags {
+				entry.Flags = append(entry.Flags, adb.PermissionFlagName(flag))
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	if s.JSON {
+		return logJSON(entries)
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PERMISSION\tGROUP\tPROTECTION\tSTATE\tFLAGS")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Name, orDash(e.Group), orDash(e.Protection), e.State, strings.Join(e.Flags, ","))
+	}
+	w.Flush()
+	util.Log(strings.TrimRight(sb.String(), "\n"))
+	return nil
+}
+
+// PermChangeStrategy grants or revokes selected runtime permissions and reports each result
+type PermChangeStrategy struct {
+	PackageName string
+	Grant       bool
+	Permissions []string
+	Groups      []string
+	Dangerous   bool
+	User        string
+}
+
+type permissionResult struct {
+	name   string
+	status string
+	reason string
+}
+
+func (s *PermChangeStrategy) Run() error {
+	if len(s.Permissions) == 0 && len(s.Groups) == 0 && !s.Dangerous {
+		return fmt.Errorf("no permission selected, pass permissions, --group or --dangerous")
+	}
+
+	info, err := adb.GetPackageInfo(s.PackageName)
+	if err != nil {
+		return err
+	}
+	defs, err := adb.ListPermissionDefs()
+	if err != nil {
+		return err
+	}
+	states := info.PermissionStates(s.User)
+
+	action, done := "revoke", "revoked"
+	if s.Grant {
+		action, done = "grant", "granted"
+	}
+
+	var results []permissionResult
+	for _, name := range s.selectPermissions(info, defs) {
+		result := permissionResult{name: name, status: "skipped"}
+		def, defined := defs[name]
+		state, hasState := states[name]
+		switch {
+		case !slices.Contains(info.RequestedPermissions, name):
+			result.reason = "not requested by the app"
+		case !defined:
+			result.reason = "not defined on the device"
+		case !def.IsRuntime():
+			result.reason = fmt.Sprintf("%s permission, not a runtime permission", orDash(def.Protection))
+		case s.Grant && state.Granted:
+			result.reason = "already granted"
+		case !s.Grant && hasState && !state.Granted:
+			result.reason = "not granted"
+		case slices.Contains(state.Flags, "SYSTEM_FIXED"):
+			result.reason = "fixed by the system"
+		case slices.Contains(state.Flags, "POLICY_FIXED"):
+			result.reason = "fixed by a device policy"
+		default:
+			change := adb.RevokePermission
+			if s.Grant {
+				change = adb.GrantPermission
+			}
+			if err := change(s.PackageName, name, s.User); err != nil {
+				result.status, result.reason = "failed", err.Error()
+			} else {
+				result.status = done
+			}
+		}
+		results = append(results, result)
+	}
+	return reportPermissionResults(action, done, results)
+}
+
+// selectPermissions returns the explicit permissions followed by the requested ones matching a group or --dangerous
+func (s *PermChangeStrategy) selectPermissions(info *adb.PackageInfo, defs map[string]adb.PermissionDef) []string {
+	var selected []string
+	for _, name := range s.Permissions {
+		if name = adb.PermissionName(name); !slices.Contains(selected, name) {
+			selected = append(selected, name)
+		}
+	}
+	for _, name := range info.RequestedPermissions {
+		if slices.Contains(selected, name) {
+			continue
+		}
+		def := defs[name]
+		if (s.Dangerous && def.IsRuntime()) || s.matchGroup(def.Group) {
+			selected = append(selected, name)
+		}
+	}
+	return selected
+}
+
+func (s *PermChangeStrategy) matchGroup(group string) bool {
+	if group == "" {
+		return false
+	}
+	for _, g := range s.Groups {
+		if strings.EqualFold(strings.TrimPrefix(g, "android.permission-group."), group) {
+			return true
+		}
+	}
+	return false
+}
+
+func reportPermissionResults(action, done string, results []permissionResult) error {
+	if len(results) == 0 {
+		util.Log("no matching permission")
+		return nil
+	}
+
+	counts := map[string]int{}
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	for _, r := range results {
+		counts[r.status]++
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.status, r.name, r.reason)
+	}
+	w.Flush()
+	util.Log(fmt.Sprintf("%s%d %s, %d failed, %d skipped", sb.String(), counts[done], done, counts["failed"], counts["skipped"]))
+
+	if counts["failed"] > 0 {
+		return fmt.Errorf("failed to %s %d permission(s)", action, counts["failed"])
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"strings"
+)
+
+// PowerStatus is the battery, doze and App Standby state shown after every power change
+type PowerStatus struct {
+	Battery   *adb.BatteryState `json:"battery"`
+	DeepIdle  string            `json:"deepIdle"`
+	LightIdle string            `json:"lightIdle"`
+	Bucket    string            `json:"standbyBucket,omitempty"`
+}
+
+// PowerStrategy simulates doze, App Standby and battery states
+type PowerStrategy struct {
+	Action      string
+	PackageName string
+	Mode        string
+	Bucket      string
+	Level       int
+	JSON        bool
+}
+
+func (s *PowerStrategy) Run() error {
+	var err error
+	switch s.Action {
+	case "status":
+	case "idle":
+		err = s.forceIdle()
+	case "step":
+		err = s.step()
+	case "unforce":
+		err = adb.UnforceIdle()
+	case "bucket":
+		err = adb.SetStandbyBucket(s.PackageName, s.Bucket)
+	case "unplug":
+		err = adb.UnplugBattery()
+	case "battery":
+		err = s.setLevel()
+	case "reset":
+		err = s.reset()
+	default:
+		return fmt.Errorf("unknown action %s", s.Action)
+	}
+	if err != nil {
+		return err
+	}
+	return s.status()
+}
+
+// forceIdle unplugs the battery first, the device never dozes while charging
+func (s *PowerStrategy) forceIdle() error {
+	if err := adb.UnplugBattery(); err != nil {
+		return err
+	}
+	output, err := adb.ForceIdle(s.Mode)
+	if err != nil {
+		return err
+	}
+	util.Log(output)
+	return nil
+}
+
+func (s *PowerStrategy) step() error {
+	if err := adb.UnplugBattery(); err != nil {
+		return err
+	}
+	output, err := adb.StepIdle(s.Mode)
+	if err != nil {
+		return err
+	}
+	util.Log(output)
+	return nil
+}
+
+func (s *PowerStrategy) setLevel() error {
+	if s.Level < 0 || s.Level > 100 {
+		return fmt.Errorf("battery level must be between 0 and 100")
+	}
+	return adb.SetBatteryLevel(s.Level)
+}
+
+func (s *PowerStrategy) reset() error {
+	if err := adb.UnforceIdle(); err != nil {
+		return err
+	}
+	if err := adb.ResetBattery(); err != nil {
+		return err
+	}
+	if s.PackageName != "" {
+		return adb.SetStandbyBucket(s.PackageName, "active")
+	}
+	return nil
+}
+
+func (s *PowerStrategy) status() error {
+	battery, err := adb.GetBatteryState()
+	if err != nil {
+		return err
+	}
+	status := &PowerStatus{Battery: battery}
+	status.DeepIdle, _ = adb.DeviceIdleState("deep")
+	status.LightIdle, _ = adb.DeviceIdleState("light")
+	if s.PackageName != "" {
+		if status.Bucket, err = adb.GetStandbyBucket(s.PackageName); err != nil {
+			return err
+		}
+	}
+
+	if s.JSON {
+		return logJSON(status)
+	}
+	util.Log(formatPowerStatus(status))
+	return nil
+}
+
+func formatPowerStatus(status *PowerStatus) string {
+	var sb strings.Builder
+	sb.WriteString(formatBattery(status.Battery))
+	fmt.Fprintf(&sb, "\n%-16s%s\n%-16s%s", "deep idle", orDash(status.DeepIdle), "light idle", orDash(status.LightIdle))
+	if status.Bucket != "" {
+		fmt.Fprintf(&sb, "\n%-16s%s", "standby bucket", status.Bucket)
+	}
+	return sb.String()
+}
+
+// formatBattery prints the battery line shared by power status and -i battery
+func formatBattery(b *adb.BatteryState) string {
+	plugged := "unplugged"
+	if len(b.Plugged) > 0 {
+		plugged = strings.Join(b.Plugged, ", ")
+	}
+	level := b.Level
+	if b.Scale > 0 {
+		level = b.Level * 100 / b.Scale
+	}
+	line := fmt.Sprintf("%-16s%d%% %s, %s, %.1f°C", "battery", level, orDash(b.Status), plugged, b.Temperature)
+	if b.Simulated {
+		line += " (simulated)"
+	}
+	return line
+}
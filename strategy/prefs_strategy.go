@@ -0,0 +1,152 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// PrefsStrategy lists, reads and edits the SharedPreferences of a debuggable app
+type PrefsStrategy struct {
+	Action      string
+	PackageName string
+	File        string
+	Key         string
+	Value       string
+	Type        string
+	JSON        bool
+}
+
+func (s *PrefsStrategy) Run() error {
+	if err := checkDebuggable(s.PackageName); err != nil {
+		return err
+	}
+
+	switch s.Action {
+	case "list":
+		if s.File == "" {
+			return s.listFiles()
+		}
+		return s.listEntries()
+	case "get":
+		return s.get()
+	case "set", "delete":
+		return s.edit()
+	}
+	return fmt.Errorf("unknown action %s", s.Action)
+}
+
+func (s *PrefsStrategy) listFiles() error {
+	files, err := adb.ListSharedPrefs(s.PackageName)
+	if err != nil {
+		return err
+	}
+	if s.JSON {
+		return logJSON(files)
+	}
+	if len(files) == 0 {
+		util.Log(fmt.Sprintf("%s has no shared preferences", s.PackageName))
+		return nil
+	}
+	util.Log(strings.Join(files, "\n"))
+	return nil
+}
+
+func (s *PrefsStrategy) listEntries() error {
+	prefs, err := adb.ReadSharedPrefs(s.PackageName, s.File)
+	if err != nil {
+		return err
+	}
+	if s.JSON {
+		return logJSON(prefs.Entries)
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTYPE\tVALUE")
+	for _, e := range prefs.Entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.Key, e.Type, e.String())
+	}
+	w.Flush()
+	util.Log(strings.TrimRight(sb.String(), "\n"))
+	return nil
+}
+
+func (s *PrefsStrategy) get() error {
+	prefs, err := adb.ReadSharedPrefs(s.PackageName, s.File)
+	if err != nil {
+		return err
+	}
+	pref, ok := prefs.Get(s.Key)
+	if !ok {
+		return fmt.Errorf("%s not found in %s", s.Key, adb.PrefFileName(s.File))
+	}
+	if s.JSON {
+		return logJSON(pref)
+	}
+	util.Log(pref.String())
+	return nil
+}
+
+// edit force-stops the app first, a running app would overwrite the file with its in-memory copy
+func (s *PrefsStrategy) edit() error {
+	files, err := adb.ListSharedPrefs(s.PackageName)
+	if err != nil {
+		return err
+	}
+	prefs := &adb.SharedPrefs{}
+	if slices.Contains(files, adb.PrefFileName(s.File)) {
+		if prefs, err = adb.ReadSharedPrefs(s.PackageName, s.File); err != nil {
+			return err
+		}
+	} else if s.Action == "delete" {
+		return fmt.Errorf("%s not found", adb.PrefFileName(s.File))
+	}
+
+	var msg string
+	if s.Action == "delete" {
+		old, ok := prefs.Get(s.Key)
+		if !ok {
+			return fmt.Errorf("%s not found in %s", s.Key, adb.PrefFileName(s.File))
+		}
+		prefs.Delete(s.Key)
+		msg = fmt.Sprintf("%s (%s %s) deleted", s.Key, old.Type, old.String())
+	} else {
+		typ := s.Type
+		old, ok := prefs.Get(s.Key)
+		if typ == "" && ok {
+			typ = old.Type
+		} else if typ == "" {
+			typ = "string"
+		}
+		pref, err := adb.NewPref(s.Key, typ, s.Value)
+		if err != nil {
+			return err
+		}
+		prefs.Put(pref)
+		msg = fmt.Sprintf("%s = %s (%s)", s.Key, pref.String(), pref.Type)
+		if ok {
+			msg = fmt.Sprintf("%s: %s -> %s (%s)", s.Key, old.String(), pref.String(), pref.Type)
+		}
+	}
+
+	if _, err := adb.Exec(fmt.Sprintf("adb shell am force-stop %s", s.PackageName), false, nil); err != nil {
+		return err
+	}
+	if err := adb.WriteSharedPrefs(s.PackageName, s.File, prefs); err != nil {
+		return err
+	}
+	util.Log(msg)
+	return nil
+}
+
+// checkDebuggable warns about non debuggable apps before run-as fails on them
+func checkDebuggable(packageName string) error {
+	if info, err := adb.GetPackageInfo(packageName); err == nil && !info.Debuggable() {
+		util.LogE(fmt.Sprintf("warning: %s is not debuggable, run-as only works on debuggable builds", packageName))
+	}
+	return adb.CheckRunAs(packageName)
+}
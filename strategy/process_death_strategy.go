@@ -0,0 +1,120 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"time"
+)
+
+// ProcessDeathStrategy kills the process of a backgrounded app while keeping its task,
+// the way the system does under memory pressure, to test savedInstanceState restoration
+type ProcessDeathStrategy struct {
+	PackageName string
+	Relaunch    bool
+	Delay       time.Duration
+}
+
+func (s *ProcessDeathStrategy) Run() error {
+	stack, err := adb.GetActivityStack()
+	if err != nil {
+		return err
+	}
+	before := adb.TopActivityOf(stack, s.PackageName)
+	if before == nil {
+		return fmt.Errorf("no activity of %s in the activity stack, start the app first", s.PackageName)
+	}
+	pid := adb.GetPID(s.PackageName)
+	if pid == 0 {
+		return fmt.Errorf("%s is not running", s.PackageName)
+	}
+	util.Log(fmt.Sprintf("[1/4] %s (pid %d, task %s)", before.Component, pid, before.TaskID))
+
+	// going home lets the activities run onSaveInstanceState and onStop before the kill
+	if err := adb.KeyEvent("KEYCODE_HOME", false); err != nil {
+		return err
+	}
+	time.Sleep(s.Delay)
+	util.Log("[2/4] moved to background")
+
+	if err := s.kill(pid); err != nil {
+		return err
+	}
+	util.Log(fmt.Sprintf("[3/4] process %d killed", pid))
+
+	if stack, err = adb.GetActivityStack(); err != nil {
+		return err
+	}
+	if adb.TopActivityOf(stack, s.PackageName) == nil {
+		return fmt.Errorf("the task of %s did not survive the kill", s.PackageName)
+	}
+
+	if !s.Relaunch {
+		util.Log(fmt.Sprintf("[4/4] task %s survived, reopen the app from recents to restore %s", before.TaskID, before.Component))
+		return nil
+	}
+	return s.relaunch(before, pid)
+}
+
+// kill uses am kill, which only kills background processes, and falls back to run-as for debuggable apps
+func (s *ProcessDeathStrategy) kill(pid int) error {
+	if _, err := adb.Exec(fmt.Sprintf("adb shell am kill %s", s.PackageName), false, nil); err != nil {
+		return err
+	}
+	if s.waitForExit(pid) {
+		return nil
+	}
+	if err := adb.CheckRunAs(s.PackageName); err != nil {
+		return fmt.Errorf("am kill did not kill %s, it may run a foreground service: %w", s.PackageName, err)
+	}
+	if _, err := adb.RunAs(s.PackageName, fmt.Sprintf("kill -9 %d", pid)); err != nil {
+		return err
+	}
+	if !s.waitForExit(pid) {
+		return fmt.Errorf("process %d of %s is still alive", pid, s.PackageName)
+	}
+	return nil
+}
+
+func (s *ProcessDeathStrategy) waitForExit(pid int) bool {
+	for i := 0; i < 10; i++ {
+		if current := adb.GetPID(s.PackageName); current == 0 || current != pid {
+			return true
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return false
+}
+
+// relaunch starts the launcher intent, which brings the surviving task to front like recents does,
+// and checks that the same activity was restored
+func (s *ProcessDeathStrategy) relaunch(before *adb.ActivityRecord, oldPID int) error {
+	component, err := adb.ResolveLauncherActivity(s.PackageName)
+	if err != nil {
+		return err
+	}
+	// FLAG_ACTIVITY_NEW_TASK | FLAG_ACTIVITY_RESET_TASK_IF_NEEDED
+	cmd := fmt.Sprintf("adb shell am start -a android.intent.action.MAIN -c android.intent.category.LAUNCHER -f 0x10200000 -n %s", component)
+	if err := runAm(cmd); err != nil {
+		return err
+	}
+	pid, err := adb.WaitForPID(s.PackageName, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	time.Sleep(s.Delay)
+
+	stack, err := adb.GetActivityStack()
+	if err != nil {
+		return err
+	}
+	after := adb.TopActivityOf(stack, s.PackageName)
+	if after == nil {
+		return fmt.Errorf("no activity of %s after relaunch", s.PackageName)
+	}
+	if after.Component != before.Component || after.TaskID != before.TaskID {
+		return fmt.Errorf("expected %s in task %s, got %s in task %s", before.Component, before.TaskID, after.Component, after.TaskID)
+	}
+	util.Log(fmt.Sprintf("[4/4] restored %s in task %s (pid %d -> %d)", after.Component, after.TaskID, oldPID, pid))
+	return nil
+}
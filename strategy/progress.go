@@ -0,0 +1,62 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"rabbit-go/util"
+	"strings"
+	"time"
+)
+
+const progressBarWidth = 30
+
+// progressBar draws the progress of a single file transfer on stderr
+type progressBar struct {
+	name     string
+	start    time.Time
+	last     time.Time
+	terminal bool
+}
+
+func newProgressBar(name string) *progressBar {
+	info, err := os.Stderr.Stat()
+	return &progressBar{
+		name:     name,
+		start:    time.Now(),
+		terminal: err == nil && info.Mode()&os.ModeCharDevice != 0,
+	}
+}
+
+func (p *progressBar) update(done, total int64) {
+	if !p.terminal || time.Since(p.last) < 100*time.Millisecond {
+		return
+	}
+	p.last = time.Now()
+
+	percent := 100.0
+	if total > 0 {
+		percent = float64(done) * 100 / float64(total)
+	}
+	filled := min(int(percent*progressBarWidth/100), progressBarWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+	if filled < progressBarWidth {
+		bar = bar[:filled] + ">" + bar[filled+1:]
+	}
+	fmt.Fprintf(os.Stderr, "\r%s %3.0f%% [%s] %s/%s %s/s\033[K", p.name, percent, bar, formatSize(done), formatSize(total), formatSize(p.rate(done)))
+}
+
+func (p *progressBar) finish(done int64) {
+	if p.terminal {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+	elapsed := time.Since(p.start)
+	util.Log(fmt.Sprintf("%s: %s in %.1fs (%s/s)", p.name, formatSize(done), elapsed.Seconds(), formatSize(p.rate(done))))
+}
+
+func (p *progressBar) rate(done int64) int64 {
+	elapsed := time.Since(p.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(done) / elapsed)
+}
@@ -2,6 +2,7 @@ package strategy
 
 import (
 	"fmt"
+	"path"
 	"rabbit-go/adb"
 	"time"
 )
@@ -14,9 +15,12 @@ type ScreenshotStrategy struct{}
 
 func (s *ScreenshotStrategy) Run() error {
 	timestamp := time.Now().Format("2006_01_02_15_04_05")
-	cmd := fmt.Sprintf("adb exec-out screencap -p > %s_screenshot.png", timestamp)
-	_, err := adb.Exec(cmd, false, nil)
-	return err
+	remote := fmt.Sprintf("/data/local/tmp/%s_screenshot.png", timestamp)
+	if _, err := adb.Exec(fmt.Sprintf("adb shell screencap -p %s", remote), false, nil); err != nil {
+		return err
+	}
+	defer adb.Exec(fmt.Sprintf("adb shell rm -f %s", remote), true, nil)
+	return pullFile(remote, path.Base(remote))
 }
 
 type Mp4RecordStrategy struct{}
@@ -0,0 +1,54 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"strings"
+	"text/tabwriter"
+)
+
+// ServiceListStrategy prints the running services of a package, or of every package
+type ServiceListStrategy struct {
+	PackageName string
+	JSON        bool
+}
+
+func (s *ServiceListStrategy) Run() error {
+	services, err := adb.GetRunningServices(s.PackageName)
+	if err != nil {
+		return err
+	}
+
+	if s.JSON {
+		return logJSON(services)
+	}
+	if len(services) == 0 {
+		util.Log("no running services")
+		return nil
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tUSER\tSTARTED\tFOREGROUND")
+	for _, service := range services {
+		fmt.Fprintf(w, "%s\t%s\t%t\t%t\n", service.Component, service.User, service.StartRequested, service.Foreground)
+	}
+	w.Flush()
+	util.Log(strings.TrimRight(sb.String(), "\n"))
+	return nil
+}
+
+// ServiceStopStrategy stops a started service
+type ServiceStopStrategy struct {
+	Component string
+	User      string
+}
+
+func (s *ServiceStopStrategy) Run() error {
+	if err := adb.StopService(s.Component, s.User); err != nil {
+		return err
+	}
+	util.Log(fmt.Sprintf("%s has been stopped", s.Component))
+	return nil
+}
@@ -0,0 +1,189 @@
+package strategy
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"rabbit-go/util"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// directories that never contain hand written sources
+var ignoredSourceDirs = map[string]bool{
+	".git":         true,
+	".gradle":      true,
+	".idea":        true,
+	"build":        true,
+	"node_modules": true,
+	".cxx":         true,
+}
+
+// SourceLocation is a class declaration found in the local project tree
+type SourceLocation struct {
+	ClassName string
+	Path      string
+	Line      int
+}
+
+// SourceStrategy maps the current activity and fragments to local source files
+type SourceStrategy struct {
+	Root    string
+	Editor  string
+	Open    bool
+	Classes []string
+}
+
+func (s *SourceStrategy) Run() error {
+	classes := s.Classes
+	if len(classes) == 0 {
+		resolved, err := resolveCurrentClasses()
+		if err != nil {
+			return err
+		}
+		classes = resolved
+	}
+
+	root := s.Root
+	if root == "" {
+		root = "."
+	}
+	files, err := collectSourceFiles(root)
+	if err != nil {
+		return err
+	}
+
+	var found []SourceLocation
+	for _, className := range classes {
+		loc, ok := findClassSource(files, className)
+		if !ok {
+			util.LogE(fmt.Sprintf("%s not found in %s", className, root))
+			continue
+		}
+		util.Log(fmt.Sprintf("%s -> %s:%d", className, loc.Path, loc.Line))
+		found = append(found, loc)
+	}
+
+	if s.Open && len(found) > 0 {
+		// the last entry is the innermost visible screen
+		return openInEditor(s.Editor, found[len(found)-1])
+	}
+	return nil
+}
+
+// resolveCurrentClasses returns the resumed activity followed by its visible fragments
+func resolveCurrentClasses() ([]string, error) {
+	screen, err := resolveCurrentScreen()
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{screen.ActivityClass()}, screen.Fragments...), nil
+}
+
+func collectSourceFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && ignoredSourceDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext == ".kt" || ext == ".java" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// findClassSource looks up a fully qualified or simple class name.
+// Files living in the matching package directory win over name-only matches,
+// and files are scanned for the declaration when no file is named after the class.
+func findClassSource(files []string, className string) (SourceLocation, bool) {
+	outer := className
+	simple := className
+	if idx := strings.Index(outer, "$"); idx != -1 {
+		outer = outer[:idx]
+		simple = className[strings.LastIndex(className, "$")+1:]
+	}
+	outerSimple := outer[strings.LastIndex(outer, ".")+1:]
+	if !strings.Contains(className, "$") {
+		simple = outerSimple
+	}
+	packageDir := ""
+	if idx := strings.LastIndex(outer, "."); idx != -1 {
+		packageDir = filepath.FromSlash(strings.ReplaceAll(outer[:idx], ".", "/"))
+	}
+	declRe := regexp.MustCompile(`\b(class|object|interface)\s+` + regexp.QuoteMeta(simple) + `\b`)
+
+	var nameMatches []string
+	for _, f := range files {
+		base := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		if base == outerSimple {
+			nameMatches = append(nameMatches, f)
+		}
+	}
+
+	var candidates []string
+	for _, f := range nameMatches {
+		dir := filepath.Dir(f)
+		if packageDir != "" && (dir == packageDir || strings.HasSuffix(dir, string(filepath.Separator)+packageDir)) {
+			candidates = append(candidates, f)
+		}
+	}
+	candidates = append(candidates, nameMatches...)
+	candidates = append(candidates, files...)
+
+	for _, f := range candidates {
+		if line := findDeclarationLine(f, declRe); line > 0 {
+			return SourceLocation{ClassName: className, Path: f, Line: line}, true
+		}
+	}
+	return SourceLocation{}, false
+}
+
+func findDeclarationLine(path string, declRe *regexp.Regexp) int {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if declRe.MatchString(scanner.Text()) {
+			return line
+		}
+	}
+	return 0
+}
+
+// openInEditor runs the editor command, replacing {file} and {line} when present
+func openInEditor(editor string, loc SourceLocation) error {
+	if editor == "" {
+		return fmt.Errorf("no editor configured, use --editor or RABBIT_EDITOR")
+	}
+	command := editor
+	if strings.Contains(command, "{file}") || strings.Contains(command, "{line}") {
+		command = strings.ReplaceAll(command, "{file}", util.ShellQuote(loc.Path))
+		command = strings.ReplaceAll(command, "{line}", strconv.Itoa(loc.Line))
+	} else {
+		command = fmt.Sprintf("%s %s", command, util.ShellQuote(fmt.Sprintf("%s:%d", loc.Path, loc.Line)))
+	}
+
+	cmd := exec.Command("/bin/sh", "-c", command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,313 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// transfer copies files and directories over a sync session and counts what was done,
+// so a failure can tell how far it got
+type transfer struct {
+	client       *adb.SyncClient
+	skipExisting bool
+	files        int
+	skipped      int
+	bytes        int64
+}
+
+func newTransfer(skipExisting bool) (*transfer, error) {
+	client, err := adb.OpenSync()
+	if err != nil {
+		return nil, err
+	}
+	return &transfer{client: client, skipExisting: skipExisting}, nil
+}
+
+func (t *transfer) close() {
+	t.client.Close()
+}
+
+// wrap adds the files done before the failure and how to resume
+func (t *transfer) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	if t.files == 0 && t.skipped == 0 {
+		return err
+	}
+	return fmt.Errorf("%w\n%d file(s) transferred and %d skipped before the failure, rerun with --skip-existing to resume", err, t.files, t.skipped)
+}
+
+func (t *transfer) summary() string {
+	msg := fmt.Sprintf("%d file(s) transferred, %s", t.files, formatSize(t.bytes))
+	if t.skipped > 0 {
+		msg += fmt.Sprintf(", %d skipped", t.skipped)
+	}
+	return msg
+}
+
+// stat resolves a symlink to the entry of its target, /sdcard is a symlink for example
+func (t *transfer) stat(remote string) (*adb.SyncEntry, error) {
+	entry, err := t.client.Stat(remote)
+	if err != nil || entry == nil || entry.Mode&os.ModeSymlink == 0 {
+		return entry, err
+	}
+	// a trailing slash makes adbd follow the link when it is a directory
+	if dir, err := t.client.Stat(remote + "/"); err == nil && dir != nil && dir.IsDir() {
+		return dir, nil
+	}
+	entry.Mode &^= os.ModeSymlink
+	return entry, nil
+}
+
+// pull copies a remote file or directory, into local/<name> when local is a directory
+func (t *transfer) pull(remote, local string) error {
+	entry, err := t.stat(remote)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("remote object %s does not exist", remote)
+	}
+	if info, err := os.Stat(local); err == nil && info.IsDir() {
+		local = filepath.Join(local, path.Base(remote))
+	}
+	return t.pullEntry(remote, local, entry)
+}
+
+func (t *transfer) pullEntry(remote, local string, entry *adb.SyncEntry) error {
+	if !entry.IsDir() {
+		return t.pullFile(remote, local, entry)
+	}
+	if err := os.MkdirAll(local, 0755); err != nil {
+		return err
+	}
+	entries, err := t.client.List(remote)
+	if err != nil {
+		return fmt.Errorf("list %s: %w", remote, err)
+	}
+	for _, e := range entries {
+		child := path.Join(remote, e.Name)
+		if e.Mode&os.ModeSymlink != 0 {
+			if e, err = t.stat(child); err != nil || e == nil {
+				continue
+			}
+		}
+		if err := t.pullEntry(child, filepath.Join(local, e.Name), e); err != nil {
+			return err
+		}
+	}
+	return os.Chtimes(local, entry.MTime, entry.MTime)
+}
+
+// pullFile writes into a .part file first, an interrupted pull never leaves a truncated file behind
+func (t *transfer) pullFile(remote, local string, entry *adb.SyncEntry) error {
+	if t.skipExisting {
+		if info, err := os.Stat(local); err == nil && info.Size() == entry.Size && info.ModTime().Unix() == entry.MTime.Unix() {
+			t.skipped++
+			return nil
+		}
+	}
+
+	part := local + ".part"
+	file, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+	bar := newProgressBar(remote)
+	done, err := t.client.Pull(remote, file, entry.Size, bar.update)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(part)
+		return fmt.Errorf("pull %s failed after %s of %s: %w", remote, formatSize(done), formatSize(entry.Size), err)
+	}
+	if err := os.Rename(part, local); err != nil {
+		return err
+	}
+	bar.finish(done)
+	t.files++
+	t.bytes += done
+	return os.Chtimes(local, entry.MTime, entry.MTime)
+}
+
+// push copies a local file or directory, into remote/<name> when remote is a directory
+func (t *transfer) push(local, remote string) error {
+	info, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	if entry, err := t.stat(remote); err == nil && entry != nil && entry.IsDir() {
+		remote = path.Join(remote, filepath.Base(local))
+	}
+	return t.pushEntry(local, remote, info)
+}
+
+// pushEntry relies on adbd creating the parent directories of every file it receives
+func (t *transfer) pushEntry(local, remote string, info os.FileInfo) error {
+	if !info.IsDir() {
+		return t.pushFile(local, remote, info)
+	}
+	entries, err := os.ReadDir(local)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		child, err := os.Stat(filepath.Join(local, e.Name()))
+		if err != nil {
+			return err
+		}
+		if err := t.pushEntry(filepath.Join(local, e.Name()), path.Join(remote, e.Name()), child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *transfer) pushFile(local, remote string, info os.FileInfo) error {
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	if t.skipExisting {
+		if entry, err := t.client.Stat(remote); err == nil && entry != nil && entry.Size == info.Size() && entry.MTime.Unix() == info.ModTime().Unix() {
+			t.skipped++
+			return nil
+		}
+	}
+
+	file, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	bar := newProgressBar(local)
+	done, err := t.client.Push(file, remote, info.Mode(), info.ModTime(), info.Size(), bar.update)
+	if err != nil {
+		return fmt.Errorf("push %s failed after %s of %s: %w", local, formatSize(done), formatSize(info.Size()), err)
+	}
+	bar.finish(done)
+	t.files++
+	t.bytes += done
+	return nil
+}
+
+// pullFile copies a single remote file or directory in its own sync session
+func pullFile(remote, local string) error {
+	t, err := newTransfer(false)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+	return t.pull(remote, local)
+}
+
+// PullStrategy copies remote files or directories to the computer
+type PullStrategy struct {
+	Remotes      []string
+	Local        string
+	SkipExisting bool
+}
+
+func (s *PullStrategy) Run() error {
+	t, err := newTransfer(s.SkipExisting)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	local := s.Local
+	if local == "" {
+		local = "."
+	}
+	if len(s.Remotes) > 1 {
+		if err := os.MkdirAll(local, 0755); err != nil {
+			return err
+		}
+	}
+	for _, remote := range s.Remotes {
+		if err := t.pull(remote, local); err != nil {
+			return t.wrap(err)
+		}
+	}
+	util.Log(t.summary())
+	return nil
+}
+
+// PushStrategy copies local files or directories to the device
+type PushStrategy struct {
+	Locals       []string
+	Remote       string
+	SkipExisting bool
+}
+
+func (s *PushStrategy) Run() error {
+	t, err := newTransfer(s.SkipExisting)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	for _, local := range s.Locals {
+		if err := t.push(local, s.Remote); err != nil {
+			return t.wrap(err)
+		}
+	}
+	util.Log(t.summary())
+	return nil
+}
+
+// LsStrategy lists a remote directory
+type LsStrategy struct {
+	Remote string
+	JSON   bool
+}
+
+func (s *LsStrategy) Run() error {
+	t, err := newTransfer(false)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	entry, err := t.stat(s.Remote)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("%s does not exist", s.Remote)
+	}
+	entries := []*adb.SyncEntry{entry}
+	if entry.IsDir() {
+		if entries, err = t.client.List(s.Remote); err != nil {
+			return err
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name < entries[j].Name
+		})
+	}
+
+	if s.JSON {
+		return logJSON(entries)
+	}
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 1, ' ', 0)
+	for _, e := range entries {
+		name := e.Name
+		if e.IsDir() {
+			name += "/"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Mode, formatSize(e.Size), e.MTime.Format("2006-01-02 15:04"), name)
+	}
+	w.Flush()
+	util.Log(strings.TrimRight(sb.String(), "\n"))
+	return nil
+}
@@ -0,0 +1,72 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"strings"
+)
+
+// UIDumpStrategy prints the uiautomator hierarchy of the foreground window
+type UIDumpStrategy struct {
+	JSON bool
+}
+
+func (s *UIDumpStrategy) Run() error {
+	hierarchy, err := adb.DumpUIHierarchy()
+	if err != nil {
+		return err
+	}
+	if s.JSON {
+		return logJSON(hierarchy)
+	}
+
+	if res, err := adb.GetCurrentPackageAndActivityName(); err == nil {
+		util.Log(strings.TrimSpace(res))
+	}
+	var sb strings.Builder
+	hierarchy.Walk(func(node *adb.UINode, depth int) {
+		fmt.Fprintf(&sb, "%s%s\n", strings.Repeat("  ", depth), node.Describe())
+	})
+	util.Log(strings.TrimSuffix(sb.String(), "\n"))
+	return nil
+}
+
+// UIFindStrategy prints the nodes matching a selector
+type UIFindStrategy struct {
+	Selector *adb.UISelector
+	JSON     bool
+}
+
+func (s *UIFindStrategy) Run() error {
+	if s.Selector.IsEmpty() {
+		return fmt.Errorf("no selector given, use --id, --text, --desc, --class or --regex")
+	}
+	hierarchy, err := adb.DumpUIHierarchy()
+	if err != nil {
+		return err
+	}
+
+	nodes := hierarchy.Find(s.Selector)
+	if s.JSON {
+		return logJSON(nodes)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no element matches the selector")
+	}
+	for _, node := range nodes {
+		x, y := node.Bounds.Center()
+		util.Log(fmt.Sprintf("%s center=(%d,%d)", node.Describe(), x, y))
+	}
+	return nil
+}
+
+func logJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	util.Log(string(data))
+	return nil
+}
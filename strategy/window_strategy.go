@@ -0,0 +1,57 @@
+package strategy
+
+import (
+	"fmt"
+	"rabbit-go/adb"
+	"rabbit-go/util"
+	"strings"
+)
+
+// TopWindowStrategy prints the window the user is actually looking at
+type TopWindowStrategy struct {
+	All bool
+}
+
+func (s *TopWindowStrategy) Run() error {
+	state, err := adb.GetWindowState()
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	if state.Focused != nil {
+		fmt.Fprintf(&sb, "focused: %s\n", formatWindow(state.Focused))
+	} else {
+		sb.WriteString("focused: none\n")
+	}
+	if state.FocusedApp != "" {
+		fmt.Fprintf(&sb, "focused app: %s\n", state.FocusedApp)
+	}
+	fmt.Fprintf(&sb, "keyboard shown: %t\n", state.KeyboardShown)
+
+	windows := state.VisibleStack()
+	if s.All {
+		windows = state.Windows
+		sb.WriteString("windows (top to bottom):\n")
+	} else {
+		sb.WriteString("visible windows (top to bottom):\n")
+	}
+	for _, w := range windows {
+		marker := " "
+		if w.Focused {
+			marker = "*"
+		}
+		fmt.Fprintf(&sb, "  %s %s\n", marker, formatWindow(w))
+	}
+
+	util.Log(strings.TrimSuffix(sb.String(), "\n"))
+	return nil
+}
+
+func formatWindow(w *adb.Window) string {
+	visibility := "hidden"
+	if w.Visible {
+		visibility = "visible"
+	}
+	return fmt.Sprintf("#%d %s package=%s type=%s %s", w.ZOrder, w.Title, w.Package, w.Type, visibility)
+}
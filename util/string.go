@@ -1,10 +1,21 @@
 package util
 
 import (
+	"regexp"
 	"strings"
 )
 
+var shellSafeRe = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
 // MultiLine splits a string by newlines
 func MultiLine(s string) []string {
 	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
 }
+
+// ShellQuote quotes s so it is passed to /bin/sh as a single word
+func ShellQuote(s string) string {
+	if shellSafeRe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}